@@ -0,0 +1,36 @@
+package integration
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	servicebapi "github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_b/api"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestCacheHeaderThroughFullStack(t *testing.T) {
+	s := startStack(t, func(b *servicebapi.Handler) {
+		b.CEPCache = cache.NewTTLCache[servicebapi.ViaCEPResponse](time.Minute)
+		b.WeatherCache = cache.NewTTLCache[servicebapi.CurrentWeather](time.Minute)
+	})
+
+	for _, want := range []string{"MISS", "HIT"} {
+		resp, err := http.Post(s.URL+"/service-a", "application/json", strings.NewReader(`{"cep":"01001000"}`))
+		if err != nil {
+			t.Fatalf("POST /service-a: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want 200", resp.StatusCode)
+		}
+		if got := resp.Header.Get(httpx.CacheHeader); got != want {
+			t.Errorf("%s = %q, want %q", httpx.CacheHeader, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestServiceBSpanJoinsServiceATrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prevTP, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	s := startStack(t, nil)
+
+	resp, err := http.Get(s.URL + "/service-a?cep=01001000")
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var root, serviceB sdktrace.ReadOnlySpan
+	for _, span := range exporter.GetSpans().Snapshots() {
+		switch span.Name() {
+		case "service-a-server":
+			root = span
+		case "service-b-server":
+			serviceB = span
+		}
+	}
+	if root == nil || serviceB == nil {
+		t.Fatalf("missing server spans: service-a %v, service-b %v", root != nil, serviceB != nil)
+	}
+	if root.Parent().IsValid() {
+		t.Errorf("service-a server span has parent %s, want a root span", root.Parent().SpanID())
+	}
+	if serviceB.SpanKind() != trace.SpanKindServer {
+		t.Errorf("service-b span kind = %s, want server", serviceB.SpanKind())
+	}
+	if got, want := serviceB.SpanContext().TraceID(), root.SpanContext().TraceID(); got != want {
+		t.Errorf("service-b trace id = %s, want service-a root trace id %s", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	serviceaapi "github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_a/api"
+	servicebapi "github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_b/api"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+)
+
+func TestConcurrentRequestsThroughFullStack(t *testing.T) {
+	s := startStack(t, func(b *servicebapi.Handler) {
+		b.CEPCache = cache.NewTTLCache[servicebapi.ViaCEPResponse](50 * time.Millisecond)
+		b.CEPCache.MaxEntries = 4
+		b.WeatherCache = cache.NewTTLCache[servicebapi.CurrentWeather](50 * time.Millisecond)
+		b.WeatherCache.MaxEntries = 4
+		b.Stampede = servicebapi.NewStampedeDetector(2, time.Second)
+		b.History = servicebapi.NewWeatherHistory(8)
+		b.AdminToken = "admin-token"
+	})
+	s.ServiceA.RateLimiter = serviceaapi.NewMemoryLimiterStore(1e6, 1e6, time.Minute)
+
+	ceps := []string{"01001000", "01310-100", "20040002", "30130-010", "40020000", "70040010"}
+
+	const workers = 16
+	const perWorker = 25
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range perWorker {
+				cep := ceps[(w+i)%len(ceps)]
+				var resp *http.Response
+				var err error
+				switch i % 4 {
+				case 0:
+					resp, err = http.Post(s.URL+"/service-a", "application/json", strings.NewReader(fmt.Sprintf(`{"cep":%q}`, cep)))
+				case 1:
+					resp, err = http.Post(s.URL+"/service-a/batch", "application/json", strings.NewReader(fmt.Sprintf(`{"ceps":[%q,%q]}`, cep, ceps[i%len(ceps)])))
+				case 2:
+					req, _ := http.NewRequest(http.MethodGet, s.URL+"/admin/status", nil)
+					req.Header.Set("Authorization", "Bearer admin-token")
+					resp, err = http.DefaultClient.Do(req)
+				default:
+					resp, err = http.Get(s.URL + "/service-a?cep=" + cep)
+				}
+				if err != nil {
+					t.Errorf("worker %d request %d: %v", w, i, err)
+					return
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("worker %d request %d: status %d", w, i, resp.StatusCode)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(s.ServiceB.History.Snapshots("São Paulo")); got == 0 {
+		t.Error("weather history is empty after the run")
+	}
+}
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	serviceaapi "github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_a/api"
+	servicebapi "github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_b/api"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+type stack struct {
+	ServiceA *serviceaapi.Handler
+	ServiceB *servicebapi.Handler
+	URL      string
+}
+
+func startStack(t *testing.T, configure func(*servicebapi.Handler)) *stack {
+	t.Helper()
+
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP","ibge":"3550308","ddd":"11"}`))
+	}))
+	t.Cleanup(viaCEP.Close)
+	weatherAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"location":{"name":"Sao Paulo","lat":-23.53,"lon":-46.62},"current":{"temp_c":23.5,"condition":{"text":"Sunny"}}}`))
+	}))
+	t.Cleanup(weatherAPI.Close)
+
+	client := &http.Client{Transport: otelhttp.NewTransport(servicebapi.NewAPIKeyTransport(http.DefaultTransport))}
+	b := servicebapi.NewHandler("test-key", client, "pt")
+	b.ViaCEPBaseURL = viaCEP.URL
+	b.BrasilAPIBaseURL = viaCEP.URL
+	b.WeatherAPIBaseURL = weatherAPI.URL
+	if configure != nil {
+		configure(b)
+	}
+	serviceB := httptest.NewServer(servicebapi.SetupRouter(b, httpx.Config{Timeout: 5 * time.Second}))
+	t.Cleanup(serviceB.Close)
+
+	balancer, err := serviceaapi.ParseBalancer(serviceB.URL+"/weather", false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	a := serviceaapi.NewHandler(balancer, http.DefaultTransport, 3)
+	a.AdminToken = b.AdminToken
+	a.ServiceBAdminToken = b.AdminToken
+	serviceA := httptest.NewServer(serviceaapi.SetupRouter(a, httpx.Config{Timeout: 5 * time.Second}))
+	t.Cleanup(serviceA.Close)
+
+	return &stack{ServiceA: a, ServiceB: b, URL: serviceA.URL}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultUnhealthyCooldown = 10 * time.Second
+
+type backend struct {
+	url            string
+	weight         int
+	currentWeight  int
+	unhealthyUntil time.Time
+}
+
+type Balancer struct {
+	mu       sync.Mutex
+	backends []*backend
+	cooldown time.Duration
+}
+
+func NormalizeServiceURL(raw string, requireHTTPS bool) (string, error) {
+	if !strings.Contains(raw, "://") {
+		scheme := "http"
+		if requireHTTPS {
+			scheme = "https"
+		}
+		raw = scheme + "://" + raw
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid service url %q: %w", raw, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q in %q", parsed.Scheme, raw)
+	}
+	if requireHTTPS && parsed.Scheme != "https" {
+		return "", fmt.Errorf("service url %q must use https", raw)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("service url %q has no host", raw)
+	}
+	return parsed.String(), nil
+}
+
+func ParseBalancer(urls string, requireHTTPS bool) (*Balancer, error) {
+	b := &Balancer{cooldown: defaultUnhealthyCooldown}
+	for _, entry := range strings.Split(urls, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		rawURL, weightStr, hasWeight := strings.Cut(entry, "|")
+		weight := 1
+		if hasWeight {
+			w, err := strconv.Atoi(strings.TrimSpace(weightStr))
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("invalid weight in %q", entry)
+			}
+			weight = w
+		}
+		normalized, err := NormalizeServiceURL(strings.TrimSpace(rawURL), requireHTTPS)
+		if err != nil {
+			return nil, err
+		}
+		b.backends = append(b.backends, &backend{url: normalized, weight: weight})
+	}
+
+	if len(b.backends) == 0 {
+		return nil, fmt.Errorf("no service-b urls configured")
+	}
+	return b, nil
+}
+
+func (b *Balancer) Next() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	candidates := make([]*backend, 0, len(b.backends))
+	for _, be := range b.backends {
+		if now.After(be.unhealthyUntil) {
+			candidates = append(candidates, be)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = b.backends
+	}
+
+	total := 0
+	var best *backend
+	for _, be := range candidates {
+		be.currentWeight += be.weight
+		total += be.weight
+		if best == nil || be.currentWeight > best.currentWeight {
+			best = be
+		}
+	}
+	best.currentWeight -= total
+	return best.url
+}
+
+func (b *Balancer) MarkDown(url string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, be := range b.backends {
+		if be.url == url {
+			be.unhealthyUntil = time.Now().Add(b.cooldown)
+		}
+	}
+}
+
+func (b *Balancer) Backends() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	urls := make([]string, 0, len(b.backends))
+	for _, be := range b.backends {
+		urls = append(urls, be.url)
+	}
+	return urls
+}
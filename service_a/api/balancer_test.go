@@ -0,0 +1,71 @@
+package api
+
+import "testing"
+
+func TestBalancerWeightedDistribution(t *testing.T) {
+	b, err := ParseBalancer("http://b1.test|3, http://b2.test|1, b3.test", false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+
+	const rounds = 1000
+	counts := map[string]int{}
+	for range rounds {
+		counts[b.Next()]++
+	}
+
+	want := map[string]int{"http://b1.test": 600, "http://b2.test": 200, "http://b3.test": 200}
+	for url, expected := range want {
+		if got := counts[url]; got < expected*95/100 || got > expected*105/100 {
+			t.Errorf("%s got %d of %d requests, want about %d", url, got, rounds, expected)
+		}
+	}
+
+	b.MarkDown("http://b1.test")
+	for range 10 {
+		if got := b.Next(); got == "http://b1.test" {
+			t.Fatal("Next() returned a backend marked down")
+		}
+	}
+}
+
+func TestParseBalancerRejectsInvalidWeights(t *testing.T) {
+	for _, urls := range []string{"http://b1.test|0", "http://b1.test|-1", "http://b1.test|x", " , "} {
+		if _, err := ParseBalancer(urls, false); err == nil {
+			t.Errorf("ParseBalancer(%q) = nil error, want error", urls)
+		}
+	}
+}
+
+func TestNormalizeServiceURL(t *testing.T) {
+	tests := []struct {
+		raw          string
+		requireHTTPS bool
+		want         string
+		wantErr      bool
+	}{
+		{raw: "service-b:8081/weather", want: "http://service-b:8081/weather"},
+		{raw: "service-b:8081/weather", requireHTTPS: true, want: "https://service-b:8081/weather"},
+		{raw: "http://service-b:8081/weather", want: "http://service-b:8081/weather"},
+		{raw: "https://service-b/weather", requireHTTPS: true, want: "https://service-b/weather"},
+		{raw: "http://service-b/weather", requireHTTPS: true, wantErr: true},
+		{raw: "ftp://service-b/weather", wantErr: true},
+		{raw: "http:///weather", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeServiceURL(tt.raw, tt.requireHTTPS)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeServiceURL(%q, %v) = %q, %v; want %q, error %v", tt.raw, tt.requireHTTPS, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseBalancerAcceptsSchemelessURLs(t *testing.T) {
+	b, err := ParseBalancer("service-b:8081/weather|2", false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	if got := b.Next(); got != "http://service-b:8081/weather" {
+		t.Errorf("Next() = %q, want http://service-b:8081/weather", got)
+	}
+}
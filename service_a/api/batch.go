@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	DefaultBatchTimeout     = 8 * time.Second
+	DefaultBatchItemTimeout = 5 * time.Second
+	DefaultMaxBatchSize     = 50
+)
+
+type BatchRequest struct {
+	CEPs []string `json:"ceps"`
+}
+
+type BatchItemResult struct {
+	CEP     string           `json:"cep"`
+	Weather *WeatherResponse `json:"weather,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Results []BatchItemResult `json:"results"`
+}
+
+func (h *Handler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("service-a")
+	ctx, span := tracer.Start(r.Context(), "service-a: handle-batch")
+	defer span.End()
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid request body")
+		WriteError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.CEPs) == 0 || len(req.CEPs) > h.MaxBatchSize {
+		span.SetStatus(codes.Error, "invalid batch size")
+		WriteError(w, fmt.Sprintf("ceps must contain between 1 and %d items", h.MaxBatchSize), http.StatusBadRequest)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("batch.size", len(req.CEPs)))
+	forwarded := forwardedParams(r)
+
+	if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		span.SetAttributes(attribute.Bool("batch.streamed", true))
+		csvw := newBatchCSVWriter(ctx, w)
+		if csvw == nil {
+			return
+		}
+		h.processBatch(ctx, req.CEPs, forwarded, csvw.write)
+		csvw.close()
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+
+	results := h.processBatch(ctx, req.CEPs, forwarded, nil)
+
+	span.SetStatus(codes.Ok, "")
+	WriteJSON(w, BatchResponse{Results: results}, http.StatusOK)
+}
+
+var batchCSVHeader = []string{"cep", "city", "temp_C", "temp_F", "temp_K", "error"}
+
+type batchCSVWriter struct {
+	ctx     context.Context
+	cw      *csv.Writer
+	flusher http.Flusher
+	failed  bool
+}
+
+func newBatchCSVWriter(ctx context.Context, w http.ResponseWriter) *batchCSVWriter {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	bw := &batchCSVWriter{ctx: ctx, cw: csv.NewWriter(w), flusher: flusher}
+	if err := bw.cw.Write(batchCSVHeader); err != nil {
+		slog.ErrorContext(ctx, "failed to write batch csv header", "error", err)
+		return nil
+	}
+	bw.flush()
+	return bw
+}
+
+func (bw *batchCSVWriter) write(result BatchItemResult) {
+	if bw.failed {
+		return
+	}
+
+	row := []string{result.CEP, "", "", "", "", result.Error}
+	if result.Weather != nil {
+		row[1] = result.Weather.City
+		row[2] = formatCSVTemp(result.Weather.TempC)
+		row[3] = formatCSVTemp(result.Weather.TempF)
+		row[4] = formatCSVTemp(result.Weather.TempK)
+	}
+	if err := bw.cw.Write(row); err != nil {
+		slog.ErrorContext(bw.ctx, "failed to write batch csv row", "cep", result.CEP, "error", err)
+		bw.failed = true
+		return
+	}
+	bw.flush()
+}
+
+func (bw *batchCSVWriter) flush() {
+	bw.cw.Flush()
+	if bw.flusher != nil {
+		bw.flusher.Flush()
+	}
+}
+
+func (bw *batchCSVWriter) close() {
+	if err := bw.cw.Error(); err != nil {
+		slog.ErrorContext(bw.ctx, "failed to flush batch csv", "error", err)
+	}
+}
+
+func (h *Handler) processBatch(ctx context.Context, ceps []string, forwarded url.Values, emit func(BatchItemResult)) []BatchItemResult {
+	ctx, cancel := context.WithTimeout(ctx, h.BatchTimeout)
+	defer cancel()
+
+	results := make([]BatchItemResult, len(ceps))
+	done := make(chan int, len(ceps))
+	for i, cep := range ceps {
+		results[i].CEP = cep
+		cep, ok := NormalizeCEP(cep)
+		if !ok {
+			results[i].Error = "invalid zipcode"
+			done <- i
+			continue
+		}
+		if !h.cepInRange(cep) {
+			results[i].Error = "zipcode out of range"
+			done <- i
+			continue
+		}
+
+		go func(i int, cep string) {
+			defer func() { done <- i }()
+
+			itemCtx, cancel := context.WithTimeout(ctx, h.BatchItemTimeout)
+			defer cancel()
+
+			weather, err := h.callServiceB(itemCtx, cep, forwarded)
+			if err != nil {
+				if itemCtx.Err() != nil || isTimeout(err) {
+					results[i].Error = "timeout"
+				} else {
+					results[i].Error = err.Error()
+				}
+				return
+			}
+			results[i].Weather = weather
+		}(i, cep)
+	}
+
+	for range ceps {
+		i := <-done
+		if emit != nil {
+			emit(results[i])
+		}
+	}
+	return results
+}
+
+func formatCSVTemp(temp *float64) string {
+	if temp == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*temp, 'f', -1, 64)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestHandleBatchStreamsCSVRows(t *testing.T) {
+	release := make(chan struct{})
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cep") == "20040002" {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"Sao Paulo","temp_C":20}`))
+	}))
+	defer serviceB.Close()
+	defer close(release)
+
+	balancer, err := ParseBalancer(serviceB.URL, false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	serviceA := httptest.NewServer(SetupRouter(NewHandler(balancer, http.DefaultTransport, 0), httpx.Config{Timeout: 5 * time.Second}))
+	defer serviceA.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, serviceA.URL+"/service-a/batch", strings.NewReader(`{"ceps":["20040002","01001000"]}`))
+	req.Header.Set("Accept", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("batch request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	for _, want := range []string{"cep,city,temp_C", "01001000,Sao Paulo,20"} {
+		select {
+		case line := <-lines:
+			if !strings.HasPrefix(line, want) {
+				t.Fatalf("line = %q, want prefix %q", line, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("row %q was not streamed before the slow item finished", want)
+		}
+	}
+
+	release <- struct{}{}
+	if line := <-lines; !strings.HasPrefix(line, "20040002,Sao Paulo,20") {
+		t.Errorf("last line = %q, want the slow item", line)
+	}
+}
+
+func TestHandleBatchItemTimeout(t *testing.T) {
+	release := make(chan struct{})
+	h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cep") == "20040002" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.Write([]byte(`{"city":"Sao Paulo","temp_C":20}`))
+	})
+	defer close(release)
+	h.BatchItemTimeout = 50 * time.Millisecond
+
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/service-a/batch", strings.NewReader(`{"ceps":["01001000","20040002","30130010"]}`)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp BatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	results := map[string]BatchItemResult{}
+	for _, result := range resp.Results {
+		results[result.CEP] = result
+	}
+	if got := results["20040002"]; got.Error != "timeout" || got.Weather != nil {
+		t.Errorf("slow item = %+v, want a timeout", got)
+	}
+	for _, cep := range []string{"01001000", "30130010"} {
+		if got := results[cep]; got.Error != "" || got.Weather == nil || got.Weather.City != "Sao Paulo" {
+			t.Errorf("item %s = %+v, want weather despite the slow item", cep, got)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+var breakerStateValues = map[string]int64{BreakerClosed: 0, BreakerOpen: 1, BreakerHalfOpen: 2}
+
+type BreakerStatus struct {
+	Target   string     `json:"target"`
+	State    string     `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	Trips    int64      `json:"trips"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+type CircuitBreaker struct {
+	Target    string
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	trips    int64
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+
+	stateGauge  prometheus.Gauge
+	tripCounter prometheus.Counter
+	otelState   metric.Int64Gauge
+	otelTrips   metric.Int64Counter
+}
+
+func NewCircuitBreaker(target string, threshold int, cooldown time.Duration, registerer prometheus.Registerer) *CircuitBreaker {
+	b := &CircuitBreaker{
+		Target:    target,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		state:     BreakerClosed,
+		now:       time.Now,
+		stateGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "circuit_breaker_state",
+			Help:        "Circuit breaker state: 0 closed, 1 open, 2 half-open",
+			ConstLabels: prometheus.Labels{"target": target},
+		}),
+		tripCounter: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "circuit_breaker_trips_total",
+			Help:        "Times the circuit breaker opened",
+			ConstLabels: prometheus.Labels{"target": target},
+		}),
+	}
+	registerer.MustRegister(b.stateGauge, b.tripCounter)
+
+	meter := otel.Meter("service-a")
+	var err error
+	if b.otelState, err = meter.Int64Gauge("circuit_breaker.state",
+		metric.WithDescription("Circuit breaker state: 0 closed, 1 open, 2 half-open")); err != nil {
+		slog.Error("failed to create circuit_breaker.state gauge", "error", err)
+	}
+	if b.otelTrips, err = meter.Int64Counter("circuit_breaker.trips",
+		metric.WithDescription("Times the circuit breaker opened")); err != nil {
+		slog.Error("failed to create circuit_breaker.trips counter", "error", err)
+	}
+	b.publish(context.Background())
+	return b
+}
+
+func (b *CircuitBreaker) Allow(ctx context.Context) bool {
+	if b == nil || b.Threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.setState(ctx, BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+func (b *CircuitBreaker) Record(ctx context.Context, success bool) {
+	if b == nil || b.Threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probing = false
+		if success {
+			b.failures = 0
+			b.setState(ctx, BreakerClosed)
+		} else {
+			b.trip(ctx)
+		}
+	case BreakerClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.Threshold {
+			b.trip(ctx)
+		}
+	}
+}
+
+func (b *CircuitBreaker) State() string {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{Target: b.Target, State: b.state, Failures: b.failures, Trips: b.trips}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+func (b *CircuitBreaker) trip(ctx context.Context) {
+	b.openedAt = b.now()
+	b.trips++
+	b.tripCounter.Inc()
+	if b.otelTrips != nil {
+		b.otelTrips.Add(ctx, 1, metric.WithAttributes(attribute.String("target", b.Target)))
+	}
+	b.setState(ctx, BreakerOpen)
+}
+
+func (b *CircuitBreaker) setState(ctx context.Context, state string) {
+	if b.state != state {
+		slog.WarnContext(ctx, "circuit breaker state changed", "target", b.Target, "from", b.state, "to", state, "failures", b.failures)
+	}
+	b.state = state
+	b.publish(ctx)
+}
+
+func (b *CircuitBreaker) publish(ctx context.Context) {
+	value := breakerStateValues[b.state]
+	b.stateGauge.Set(float64(value))
+	if b.otelState != nil {
+		b.otelState.Record(ctx, value, metric.WithAttributes(attribute.String("target", b.Target)))
+	}
+}
+
+func (h *Handler) BreakersHandler(w http.ResponseWriter, r *http.Request) {
+	breakers := []BreakerStatus{}
+	if h.Breaker != nil {
+		breakers = append(breakers, h.Breaker.Status())
+	}
+	WriteJSON(w, map[string][]BreakerStatus{"breakers": breakers}, http.StatusOK)
+}
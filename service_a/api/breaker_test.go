@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestCircuitBreakerLifecycle(t *testing.T) {
+	var failing atomic.Bool
+	var calls atomic.Int32
+	failing.Store(true)
+
+	balancer, err := ParseBalancer("http://service-b.test/", false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	h := NewHandler(balancer, http.DefaultTransport, 0)
+	h.HTTPClient = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		calls.Add(1)
+		if failing.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(`{"city":"Sao Paulo"}`)),
+		}, nil
+	})}
+
+	now := time.Unix(0, 0)
+	h.Breaker.now = func() time.Time { return now }
+	h.Breaker.Threshold = 2
+	h.Breaker.Cooldown = 30 * time.Second
+	ctx := context.Background()
+
+	call := func() error {
+		_, err := h.callServiceB(ctx, "01001000", nil)
+		return err
+	}
+	assertState := func(want string) {
+		t.Helper()
+		if got := h.Breaker.State(); got != want {
+			t.Fatalf("state = %q, want %q", got, want)
+		}
+	}
+
+	call()
+	assertState(BreakerClosed)
+	call()
+	assertState(BreakerOpen)
+
+	if err := call(); err == nil || err.Error() != "circuit breaker open" {
+		t.Fatalf("call while open = %v, want circuit breaker open", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("service-b calls while open = %d, want 2", got)
+	}
+
+	now = now.Add(10 * time.Second)
+	if err := call(); err == nil || err.Error() != "circuit breaker open" {
+		t.Fatalf("call before cooldown = %v, want circuit breaker open", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	if !h.Breaker.Allow(ctx) {
+		t.Fatal("Allow() after cooldown = false, want a half-open probe")
+	}
+	assertState(BreakerHalfOpen)
+	if h.Breaker.Allow(ctx) {
+		t.Fatal("second Allow() while probing = true, want false")
+	}
+	h.Breaker.Record(ctx, false)
+	assertState(BreakerOpen)
+
+	now = now.Add(31 * time.Second)
+	failing.Store(false)
+	if err := call(); err != nil {
+		t.Fatalf("half-open probe: %v", err)
+	}
+	assertState(BreakerClosed)
+
+	status := h.Breaker.Status()
+	if status.Trips != 2 || status.Failures != 0 {
+		t.Errorf("status = %+v, want 2 trips and no failures", status)
+	}
+}
+
+func TestBreakersEndpointReflectsTrip(t *testing.T) {
+	balancer, err := ParseBalancer("http://service-b.test/", false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	h := NewHandler(balancer, http.DefaultTransport, 0)
+	h.AdminToken = "secret"
+	now := time.Unix(1700000000, 0).UTC()
+	h.Breaker.now = func() time.Time { return now }
+	h.Breaker.Threshold = 1
+	h.Breaker.Record(context.Background(), false)
+
+	router := SetupRouter(h, httpx.Config{Timeout: 5 * time.Second})
+	req := httptest.NewRequest(http.MethodGet, "/admin/breakers", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var body struct {
+		Breakers []BreakerStatus `json:"breakers"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(body.Breakers) != 1 {
+		t.Fatalf("breakers = %+v, want one", body.Breakers)
+	}
+	got := body.Breakers[0]
+	if got.Target != "service-b" || got.State != BreakerOpen || got.Trips != 1 {
+		t.Errorf("breaker = %+v, want service-b open with one trip", got)
+	}
+	if got.OpenedAt == nil || !got.OpenedAt.Equal(now) {
+		t.Errorf("opened_at = %v, want %v", got.OpenedAt, now)
+	}
+}
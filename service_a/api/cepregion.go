@@ -0,0 +1,33 @@
+package api
+
+type cepRegion struct {
+	Min  string
+	Max  string
+	Name string
+}
+
+var cepRegions = [10]cepRegion{
+	{"01000000", "09999999", "Grande São Paulo"},
+	{"10000000", "19999999", "interior de São Paulo"},
+	{"20000000", "29999999", "RJ/ES"},
+	{"30000000", "39999999", "MG"},
+	{"40000000", "49999999", "BA/SE"},
+	{"50000000", "59999999", "PE/AL/PB/RN"},
+	{"60000000", "69999999", "CE/PI/MA/PA/AP/AM/RR/AC"},
+	{"70000000", "79999999", "DF/GO/TO/MT/RO/MS"},
+	{"80000000", "89999999", "PR/SC"},
+	{"90000000", "99999999", "RS"},
+}
+
+func cepRegionFor(cep string) (cepRegion, bool) {
+	region := cepRegions[cep[0]-'0']
+	return region, cep >= region.Min && cep <= region.Max
+}
+
+func (h *Handler) cepInRange(cep string) bool {
+	if !h.StrictCEPRanges {
+		return true
+	}
+	_, ok := cepRegionFor(cep)
+	return ok
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+)
+
+var breakerNeutralCodes = []string{"MAINTENANCE", "TEMPERATURE_UNAVAILABLE", "UPSTREAM_BUDGET_EXCEEDED"}
+
+type downstreamError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *downstreamError) Error() string {
+	return e.Message
+}
+
+func (e *downstreamError) countsAsBreakerFailure() bool {
+	return e.Status >= http.StatusInternalServerError && !slices.Contains(breakerNeutralCodes, e.Code)
+}
+
+func decodeDownstreamError(resp *http.Response) *downstreamError {
+	var errResp ErrorResponse
+	_ = json.NewDecoder(io.LimitReader(resp.Body, maxLoggedBodySize)).Decode(&errResp)
+	if errResp.Message == "" {
+		errResp.Message = "failed to get weather data"
+	}
+	return &downstreamError{Status: resp.StatusCode, Code: errResp.Code, Message: errResp.Message}
+}
+
+func serviceBErrorResponse(err error) (ErrorResponse, int) {
+	var downstream *downstreamError
+	if errors.As(err, &downstream) {
+		return ErrorResponse{Message: downstream.Message, Code: downstream.Code}, downstream.Status
+	}
+	switch err.Error() {
+	case "cannot find zipcode":
+		return ErrorResponse{Message: "can not find zipcode"}, http.StatusNotFound
+	case "invalid zipcode":
+		return ErrorResponse{Message: "invalid zipcode"}, http.StatusUnprocessableEntity
+	case "reserved zipcode":
+		return ErrorResponse{Message: "zipcode is in a reserved range", Code: "RESERVED_ZIPCODE"}, http.StatusUnprocessableEntity
+	case "bad gateway":
+		return ErrorResponse{Message: "invalid response from weather service", Code: "UPSTREAM_INVALID_RESPONSE"}, http.StatusBadGateway
+	case "service-b timeout":
+		return ErrorResponse{Message: "weather service timed out", Code: "DOWNSTREAM_TIMEOUT"}, http.StatusGatewayTimeout
+	case "circuit breaker open":
+		return ErrorResponse{Message: "weather service unavailable", Code: "CIRCUIT_OPEN"}, http.StatusServiceUnavailable
+	}
+	return ErrorResponse{Message: "failed to get weather data"}, http.StatusInternalServerError
+}
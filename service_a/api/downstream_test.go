@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHandleCEPPropagatesServiceBErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantStatus      int
+		wantCode        string
+		wantMessage     string
+		wantBreakerFail bool
+	}{
+		{
+			name:        "temperature unavailable",
+			status:      http.StatusServiceUnavailable,
+			body:        `{"message":"temperature not available","code":"TEMPERATURE_UNAVAILABLE"}`,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    "TEMPERATURE_UNAVAILABLE",
+			wantMessage: "temperature not available",
+		},
+		{
+			name:        "maintenance",
+			status:      http.StatusServiceUnavailable,
+			body:        `{"message":"service under maintenance, please try again later","code":"MAINTENANCE"}`,
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    "MAINTENANCE",
+			wantMessage: "service under maintenance, please try again later",
+		},
+		{
+			name:            "upstream timeout",
+			status:          http.StatusGatewayTimeout,
+			body:            `{"message":"upstream timed out","code":"UPSTREAM_TIMEOUT"}`,
+			wantStatus:      http.StatusGatewayTimeout,
+			wantCode:        "UPSTREAM_TIMEOUT",
+			wantMessage:     "upstream timed out",
+			wantBreakerFail: true,
+		},
+		{
+			name:            "internal error without body",
+			status:          http.StatusInternalServerError,
+			body:            `oops`,
+			wantStatus:      http.StatusInternalServerError,
+			wantMessage:     "failed to get weather data",
+			wantBreakerFail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer serviceB.Close()
+
+			balancer, err := ParseBalancer(serviceB.URL, false)
+			if err != nil {
+				t.Fatalf("ParseBalancer: %v", err)
+			}
+			h := NewHandler(balancer, http.DefaultTransport, 0)
+
+			rec := httptest.NewRecorder()
+			h.HandleCEP(rec, httptest.NewRequest(http.MethodGet, "/service-a?cep=01001000", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Code != tt.wantCode || resp.Message != tt.wantMessage {
+				t.Errorf("response = %+v, want code %q message %q", resp, tt.wantCode, tt.wantMessage)
+			}
+			if failed := h.Breaker.Status().Failures == 1; failed != tt.wantBreakerFail {
+				t.Errorf("breaker failure recorded = %v, want %v", failed, tt.wantBreakerFail)
+			}
+		})
+	}
+}
+
+func TestHandleCEPMalformedServiceBResponse(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	garbage := `{"city":"São Paulo","temp_C":` + strings.Repeat("9", 2*maxLoggedBodySize)
+	h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(garbage))
+	})
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/service-a?cep=01001000", nil))
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502: %s", rec.Code, rec.Body)
+	}
+	var resp ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Code != "UPSTREAM_INVALID_RESPONSE" {
+		t.Errorf("code = %q, want UPSTREAM_INVALID_RESPONSE", resp.Code)
+	}
+
+	var recorded string
+	for _, span := range recorder.Ended() {
+		for _, kv := range span.Attributes() {
+			if kv.Key == "service_b.response_body" {
+				recorded = kv.Value.AsString()
+			}
+		}
+	}
+	if want := garbage[:maxLoggedBodySize] + "..."; recorded != want {
+		t.Errorf("service_b.response_body = %q, want the body truncated to %d bytes", recorded, maxLoggedBodySize)
+	}
+}
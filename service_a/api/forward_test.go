@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleCEPForwardsOptionalFields(t *testing.T) {
+	var query map[string][]string
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"Sao Paulo","temp_C":23.5,"lat":-23.53,"lon":-46.62,"formatted":{"celsius":"23,5 °C","fahrenheit":"74,3 °F","kelvin":"296,5 K"}}`))
+	}))
+	defer serviceB.Close()
+
+	balancer, err := ParseBalancer(serviceB.URL, false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	h := NewHandler(balancer, http.DefaultTransport, 0)
+
+	rec := httptest.NewRecorder()
+	h.HandleCEP(rec, httptest.NewRequest(http.MethodGet, "/service-a?cep=01001000&include_coords=true&formatted=true&lang=pt&ignored=1", nil))
+
+	for key, want := range map[string]string{"include_coords": "true", "formatted": "true", "lang": "pt"} {
+		if got := query[key]; len(got) != 1 || got[0] != want {
+			t.Errorf("forwarded %s = %v, want [%s]", key, got, want)
+		}
+	}
+	if _, ok := query["ignored"]; ok {
+		t.Error("unknown query params must not be forwarded")
+	}
+
+	var resp WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Lat == nil || resp.Lon == nil || *resp.Lat != -23.53 || *resp.Lon != -46.62 {
+		t.Errorf("coords = %v, %v, want -23.53, -46.62", resp.Lat, resp.Lon)
+	}
+	if resp.Formatted == nil || resp.Formatted.Celsius != "23,5 °C" {
+		t.Errorf("formatted = %+v, want celsius 23,5 °C", resp.Formatted)
+	}
+}
+
+func TestHandleCEPForwardsIncludeCodes(t *testing.T) {
+	var query map[string][]string
+	h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city":"São Paulo","temp_C":23.5,"ibge":"3550308","ddd":"11"}`))
+	})
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/service-a?cep=01001000&include_codes=true", nil))
+
+	if got := query["include_codes"]; len(got) != 1 || got[0] != "true" {
+		t.Errorf("forwarded include_codes = %v, want [true]", got)
+	}
+	var resp WeatherResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.IBGE != "3550308" || resp.DDD != "11" {
+		t.Errorf("ibge, ddd = %q, %q, want 3550308, 11", resp.IBGE, resp.DDD)
+	}
+}
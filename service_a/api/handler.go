@@ -3,43 +3,132 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 )
 
+const maxLoggedBodySize = 512
+
 type Handler struct {
-	ServiceBURL string
+	ServiceB       *Balancer
+	Transport      http.RoundTripper
+	HTTPClient     *http.Client
+	RequestTimeout time.Duration
+	StartedAt      time.Time
+	Readiness      *httpx.Readiness
+	AdminToken     string
+	LogLevel       *slog.LevelVar
+	Metrics        *httpx.HTTPMetrics
+	OTelMetrics    *httpx.OTelMetrics
+
+	ServiceBAdminToken string
+
+	BatchTimeout     time.Duration
+	BatchItemTimeout time.Duration
+	MaxBatchSize     int
+	MaxValidateSize  int
+
+	TraceURLTemplate string
+	Debug            bool
+	StrictCEPRanges  bool
+
+	Breaker     *CircuitBreaker
+	RateLimiter LimiterStore
+
+	serviceBCalls metric.Int64Counter
 }
 
-func NewHandler(serviceBURL string) *Handler {
-	return &Handler{ServiceBURL: serviceBURL}
+func NewServiceBClient(transport http.RoundTripper, maxRedirects int) *http.Client {
+	return &http.Client{
+		Transport:     otelhttp.NewTransport(httpx.CountingTransport(transport)),
+		CheckRedirect: httpx.CheckRedirect(maxRedirects),
+	}
 }
 
-func (h *Handler) callServiceB(ctx context.Context, cep string) (*WeatherResponse, error) {
+func NewHandler(serviceB *Balancer, transport http.RoundTripper, maxRedirects int) *Handler {
+	serviceBCalls, err := otel.Meter("service-a").Int64Counter("service_b.calls",
+		metric.WithDescription("Calls from service-a to service-b by result"))
+	if err != nil {
+		slog.Error("failed to create service_b.calls counter", "error", err)
+	}
+
+	metrics := httpx.NewHTTPMetrics("service-a")
+	return &Handler{
+		ServiceB:       serviceB,
+		Transport:      transport,
+		HTTPClient:     NewServiceBClient(transport, maxRedirects),
+		RequestTimeout: httpx.DefaultRequestTimeout,
+		StartedAt:      time.Now(),
+		Metrics:        metrics,
+		OTelMetrics:    httpx.NewOTelMetrics("service-a"),
+		serviceBCalls:  serviceBCalls,
+
+		BatchTimeout:     DefaultBatchTimeout,
+		BatchItemTimeout: DefaultBatchItemTimeout,
+		MaxBatchSize:     DefaultMaxBatchSize,
+		MaxValidateSize:  DefaultMaxValidateSize,
+
+		Breaker: NewCircuitBreaker("service-b", DefaultBreakerThreshold, DefaultBreakerCooldown, metrics.Registry),
+	}
+}
+
+func (h *Handler) callServiceB(ctx context.Context, cep string, forwarded url.Values) (*WeatherResponse, error) {
 	tracer := otel.Tracer("service-a")
 	ctx, span := tracer.Start(ctx, "service-a: call-service-b")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("cep", cep))
 
-	log.Printf("Calling Service B with CEP: %s", cep)
+	result := "downstream_error"
+	defer func() {
+		h.serviceBCalls.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+	}()
+
+	if !h.Breaker.Allow(ctx) {
+		err := fmt.Errorf("circuit breaker open")
+		span.SetAttributes(attribute.String("circuit_breaker.state", BreakerOpen))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "circuit breaker open")
+		result = "circuit_open"
+		return nil, err
+	}
+	breakerFailure := false
+	defer func() {
+		h.Breaker.Record(ctx, !breakerFailure)
+	}()
+	span.SetAttributes(attribute.String("circuit_breaker.state", h.Breaker.State()))
+
+	slog.InfoContext(ctx, "calling service b", "cep", cep)
+
+	ctx, cancel := context.WithTimeout(ctx, h.RequestTimeout)
+	defer cancel()
+
+	serviceBURL := h.ServiceB.Next()
+	span.SetAttributes(attribute.String("service_b.url", serviceBURL))
 
-	client := &http.Client{
-		Timeout:   5 * time.Second,
-		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	query := url.Values{"cep": {cep}}
+	for key, values := range forwarded {
+		query[key] = values
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.ServiceBURL+"?cep="+cep, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serviceBURL+"?"+query.Encode(), nil)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to create request")
@@ -47,12 +136,21 @@ func (h *Handler) callServiceB(ctx context.Context, cep string) (*WeatherRespons
 	}
 
 	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	httpx.SetNextHop(ctx, req)
+	httpx.SetRequestID(ctx, req)
 
-	resp, err := client.Do(req)
+	resp, err := h.HTTPClient.Do(req)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to call service-b")
-		log.Printf("Error calling service B: %v", err)
+		slog.ErrorContext(ctx, "service b call failed", "url", serviceBURL, "error", err)
+		h.ServiceB.MarkDown(serviceBURL)
+		breakerFailure = true
+		if isTimeout(err) {
+			span.SetAttributes(attribute.String("error.type", "timeout"))
+			result = "downstream_timeout"
+			return nil, fmt.Errorf("service-b timeout")
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
@@ -67,6 +165,13 @@ func (h *Handler) callServiceB(ctx context.Context, cep string) (*WeatherRespons
 	}
 
 	if resp.StatusCode == http.StatusUnprocessableEntity {
+		var errResp ErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil && errResp.Code == "RESERVED_ZIPCODE" {
+			err := fmt.Errorf("reserved zipcode")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "reserved zipcode")
+			return nil, err
+		}
 		err := fmt.Errorf("invalid zipcode")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "invalid zipcode")
@@ -74,53 +179,102 @@ func (h *Handler) callServiceB(ctx context.Context, cep string) (*WeatherRespons
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf("service-b returned status %d", resp.StatusCode)
+		err := decodeDownstreamError(resp)
+		breakerFailure = err.countsAsBreakerFailure()
+		span.SetAttributes(attribute.String("service_b.error_code", err.Code))
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "unexpected status from service-b")
 		return nil, err
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response body")
+		return nil, fmt.Errorf("failed to read service-b response body: %w", err)
+	}
+
 	var weather WeatherResponse
-	if err := json.NewDecoder(resp.Body).Decode(&weather); err != nil {
+	if err := json.Unmarshal(body, &weather); err != nil {
+		span.SetAttributes(attribute.String("service_b.response_body", truncate(string(body), maxLoggedBodySize)))
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to decode response")
-		return nil, err
+		span.SetStatus(codes.Error, "malformed response from service-b")
+		result = "bad_response"
+		return nil, fmt.Errorf("bad gateway")
 	}
 
+	weather.Cache = resp.Header.Get(httpx.CacheHeader)
+
+	h.Readiness.MarkReady()
+	result = "ok"
 	span.SetStatus(codes.Ok, "")
 	return &weather, nil
 }
 
-func (h *Handler) validateCEP(ctx context.Context, r *http.Request) (*CEPRequest, error) {
+func (h *Handler) decodeCEPRequest(ctx context.Context, r *http.Request) (string, error) {
 	tracer := otel.Tracer("service-a")
-	_, span := tracer.Start(ctx, "service-a: validate-cep")
+	_, span := tracer.Start(ctx, "service-a: decode-cep-request")
 	defer span.End()
 
+	if r.Method == http.MethodGet {
+		span.SetAttributes(attribute.String("request.source", "query"))
+		span.SetStatus(codes.Ok, "")
+		return r.URL.Query().Get("cep"), nil
+	}
+
 	var req CEPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "invalid form body")
+			return "", fmt.Errorf("invalid request")
+		}
+		req.CEP = r.PostFormValue("cep")
+		span.SetAttributes(attribute.String("request.content_type", mediaType))
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "invalid request body")
-		return nil, fmt.Errorf("invalid request")
+		return "", fmt.Errorf("invalid request")
 	}
 
-	if req.CEP == "" {
+	span.SetStatus(codes.Ok, "")
+	return req.CEP, nil
+}
+
+func (h *Handler) validateCEP(ctx context.Context, cep string) (string, error) {
+	tracer := otel.Tracer("service-a")
+	_, span := tracer.Start(ctx, "service-a: validate-cep")
+	defer span.End()
+
+	if cep == "" {
 		err := fmt.Errorf("cep is required")
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "cep is required")
-		return nil, err
+		return "", err
 	}
 
-	if !IsValidCEP(req.CEP) {
+	normalized, ok := NormalizeCEP(cep)
+	if !ok {
 		err := fmt.Errorf("invalid zipcode")
-		span.SetAttributes(attribute.String("cep", req.CEP))
+		span.SetAttributes(attribute.String("cep", cep))
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "invalid zipcode format")
-		return nil, err
+		return "", err
+	}
+
+	span.SetAttributes(attribute.String("cep", normalized))
+	if h.StrictCEPRanges {
+		if region, ok := cepRegionFor(normalized); !ok {
+			err := fmt.Errorf("zipcode out of range")
+			span.SetAttributes(attribute.String("cep.region", region.Name))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "zipcode outside allocated ranges")
+			return "", err
+		}
 	}
 
-	span.SetAttributes(attribute.String("cep", req.CEP))
 	span.SetStatus(codes.Ok, "")
-	return &req, nil
+	return normalized, nil
 }
 
 func (h *Handler) HandleCEP(w http.ResponseWriter, r *http.Request) {
@@ -128,7 +282,10 @@ func (h *Handler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "service-a: handle-cep")
 	defer span.End()
 
-	req, err := h.validateCEP(ctx, r)
+	cep, err := h.decodeCEPRequest(ctx, r)
+	if err == nil {
+		cep, err = h.validateCEP(ctx, cep)
+	}
 	if err != nil {
 		span.RecordError(err)
 		switch err.Error() {
@@ -141,50 +298,108 @@ func (h *Handler) HandleCEP(w http.ResponseWriter, r *http.Request) {
 		case "invalid zipcode":
 			span.SetStatus(codes.Error, "invalid zipcode")
 			WriteError(w, "invalid zipcode", http.StatusUnprocessableEntity)
+		case "zipcode out of range":
+			span.SetStatus(codes.Error, "zipcode out of range")
+			WriteErrorCode(w, "zipcode is outside the ranges allocated in Brazil", "CEP_OUT_OF_RANGE", http.StatusUnprocessableEntity)
 		}
 		return
 	}
 
-	span.SetAttributes(attribute.String("cep", req.CEP))
-	log.Printf("Processing CEP: %s", req.CEP)
+	span.SetAttributes(attribute.String("cep", cep))
+	slog.InfoContext(ctx, "processing cep", "cep", cep)
 
-	weatherData, err := h.callServiceB(ctx, req.CEP)
+	weatherData, err := h.callServiceB(ctx, cep, forwardedParams(r))
 	if err != nil {
-		log.Printf("Error calling service B: %v", err)
+		slog.ErrorContext(ctx, "service b returned an error", "cep", cep, "error", err)
 		span.RecordError(err)
-		switch err.Error() {
-		case "cannot find zipcode":
-			span.SetStatus(codes.Error, "zipcode not found")
-			WriteError(w, "can not find zipcode", http.StatusNotFound)
-		case "invalid zipcode":
-			span.SetStatus(codes.Error, "invalid zipcode")
-			WriteError(w, "invalid zipcode", http.StatusUnprocessableEntity)
-		default:
-			span.SetStatus(codes.Error, "failed to get weather data")
-			WriteError(w, "failed to get weather data", http.StatusInternalServerError)
-		}
+		errResp, status := serviceBErrorResponse(err)
+		span.SetStatus(codes.Error, errResp.Message)
+		WriteErrorCode(w, errResp.Message, errResp.Code, status)
 		return
 	}
 
+	if weatherData.Cache != "" {
+		w.Header().Set(httpx.CacheHeader, weatherData.Cache)
+	}
+	resp := WeatherResponse{
+		CEP:       weatherData.CEP,
+		City:      weatherData.City,
+		TempC:     weatherData.TempC,
+		TempF:     weatherData.TempF,
+		TempK:     weatherData.TempK,
+		TempR:     weatherData.TempR,
+		TempRe:    weatherData.TempRe,
+		Condition: weatherData.Condition,
+		Degraded:  weatherData.Degraded,
+		Stale:     weatherData.Stale,
+		IBGE:      weatherData.IBGE,
+		DDD:       weatherData.DDD,
+		UTCOffset: weatherData.UTCOffset,
+		TraceURL:  httpx.TraceURL(ctx, h.TraceURLTemplate),
+		FetchedAt: weatherData.FetchedAt,
+		Lat:       weatherData.Lat,
+		Lon:       weatherData.Lon,
+		Formatted: weatherData.Formatted,
+	}
+	if h.Debug {
+		resp.Debug = weatherData.Debug
+	}
+
 	span.SetStatus(codes.Ok, "")
-	WriteJSON(w, WeatherResponse{
-		City:  weatherData.City,
-		TempC: weatherData.TempC,
-		TempF: weatherData.TempF,
-		TempK: weatherData.TempK,
-	}, http.StatusOK)
+	WriteJSON(w, resp, http.StatusOK)
+}
+
+var forwardedQueryParams = []string{"include_codes", "include_timezone", "include_coords", "formatted", "lang"}
+
+func forwardedParams(r *http.Request) url.Values {
+	forwarded := url.Values{}
+	for _, key := range forwardedQueryParams {
+		if value := r.URL.Query().Get(key); value != "" {
+			forwarded.Set(key, value)
+		}
+	}
+	return forwarded
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func methodNotAllowed(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if routes.Match(chi.NewRouteContext(), method, r.URL.Path) {
+				allowed = append(allowed, method)
+			}
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		WriteErrorCode(w, "method not allowed", "METHOD_NOT_ALLOWED", http.StatusMethodNotAllowed)
+	}
 }
 
-func SetupRouter(h *Handler) http.Handler {
+func SetupRouter(h *Handler, cfg httpx.Config) http.Handler {
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(httpx.DefaultMiddleware(cfg)...)
+	r.Use(h.Metrics.Middleware, h.OTelMetrics.Middleware)
 
-	r.Post("/service-a", h.HandleCEP)
+	r.Group(func(r chi.Router) {
+		r.Use(RateLimit(h.RateLimiter), httpx.Maintenance(cfg.MaintenanceMode), httpx.DecompressBody(cfg.MaxDecompressedBody))
+		r.Post("/service-a", h.HandleCEP)
+		r.Get("/service-a", h.HandleCEP)
+		r.Post("/service-a/batch", h.HandleBatch)
+		r.Post("/service-a/validate", h.HandleValidate)
+	})
+	r.MethodNotAllowed(methodNotAllowed(r))
+	r.With(httpx.RequireToken(h.AdminToken)).Get("/admin/status", h.StatusHandler)
+	r.With(httpx.RequireToken(h.AdminToken)).Post("/admin/loglevel", httpx.LogLevelHandler(h.LogLevel))
+	r.With(httpx.RequireToken(h.AdminToken)).Post("/admin/replay", h.HandleReplay)
+	r.With(httpx.RequireToken(h.AdminToken)).Get("/admin/breakers", h.BreakersHandler)
+	r.Get("/healthz", httpx.Healthz)
+	r.Get("/readyz", h.Readiness.Handler)
+	r.Handle("/metrics", h.Metrics.Handler())
 
 	return otelhttp.NewHandler(r, "service-a-server")
 }
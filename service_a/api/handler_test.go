@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(h *Handler)
+		method     string
+		target     string
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "method not allowed",
+			method:     http.MethodDelete,
+			target:     "/service-a",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "METHOD_NOT_ALLOWED",
+		},
+		{
+			name: "rate limited",
+			setup: func(h *Handler) {
+				store := NewMemoryLimiterStore(0.001, 1, time.Minute)
+				store.Allow("192.0.2.1")
+				h.RateLimiter = store
+			},
+			method:     http.MethodGet,
+			target:     "/service-a?cep=01001000",
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   "RATE_LIMITED",
+		},
+		{
+			name: "circuit open",
+			setup: func(h *Handler) {
+				h.Breaker.Threshold = 1
+				h.Breaker.Record(context.Background(), false)
+			},
+			method:     http.MethodGet,
+			target:     "/service-a?cep=01001000",
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "CIRCUIT_OPEN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balancer, err := ParseBalancer("http://service-b.test/", false)
+			if err != nil {
+				t.Fatalf("ParseBalancer: %v", err)
+			}
+			h := NewHandler(balancer, http.DefaultTransport, 0)
+			if tt.setup != nil {
+				tt.setup(h)
+			}
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			SetupRouter(h, httpx.Config{Timeout: 5 * time.Second}).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// newStubbedHandler returns a handler whose service B is an httptest server
+// running serviceB.
+func newStubbedHandler(t *testing.T, serviceB http.HandlerFunc) *Handler {
+	t.Helper()
+	server := httptest.NewServer(serviceB)
+	t.Cleanup(server.Close)
+
+	balancer, err := ParseBalancer(server.URL+"/weather", false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	return NewHandler(balancer, http.DefaultTransport, 0)
+}
+
+func serve(h *Handler, req *http.Request) *httptest.ResponseRecorder {
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec := httptest.NewRecorder()
+	SetupRouter(h, httpx.Config{Timeout: 5 * time.Second}).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStrictCEPRangesRejectsAllZeros(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		wantStatus int
+		wantCalls  int32
+		wantCode   string
+	}{
+		{"default mode", false, http.StatusOK, 1, ""},
+		{"strict mode", true, http.StatusUnprocessableEntity, 0, "CEP_OUT_OF_RANGE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Write([]byte(`{"city":"Sao Paulo"}`))
+			})
+			h.StrictCEPRanges = tt.strict
+
+			rec := serve(h, httptest.NewRequest(http.MethodGet, "/service-a?cep=00000000", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("service-b calls = %d, want %d", got, tt.wantCalls)
+			}
+			var resp ErrorResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestHandleCEPAcceptsFormEncodedBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"form", "application/x-www-form-urlencoded", "cep=01001-000", http.StatusOK},
+		{"form with charset", "application/x-www-form-urlencoded; charset=utf-8", "cep=01001000", http.StatusOK},
+		{"form without cep", "application/x-www-form-urlencoded", "zip=01001000", http.StatusBadRequest},
+		{"json", "application/json", `{"cep":"01001000"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cep string
+			h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+				cep = r.URL.Query().Get("cep")
+				w.Write([]byte(`{"city":"Sao Paulo"}`))
+			})
+			req := httptest.NewRequest(http.MethodPost, "/service-a", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rec := serve(h, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK && cep != "01001000" {
+				t.Errorf("service-b got cep %q, want 01001000", cep)
+			}
+		})
+	}
+}
+
+func TestMethodNotAllowedListsAllowedMethods(t *testing.T) {
+	tests := []struct {
+		method    string
+		target    string
+		wantAllow string
+	}{
+		{http.MethodDelete, "/service-a", "GET, POST"},
+		{http.MethodPut, "/service-a", "GET, POST"},
+		{http.MethodGet, "/service-a/validate", "POST"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+
+			rec := serve(h, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("status = %d, want 405", rec.Code)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var resp ErrorResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if resp.Code != "METHOD_NOT_ALLOWED" || resp.Message == "" {
+				t.Errorf("body = %+v, want a METHOD_NOT_ALLOWED error", resp)
+			}
+		})
+	}
+}
+
+func BenchmarkCallServiceBReusesConnections(b *testing.B) {
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	previousLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	b.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		slog.SetDefault(previousLogger)
+	})
+
+	var missingTraceparent atomic.Int32
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("traceparent") == "" {
+			missingTraceparent.Add(1)
+		}
+		w.Write([]byte(`{"city":"Sao Paulo","temp_C":23.5}`))
+	}))
+	defer serviceB.Close()
+
+	balancer, err := ParseBalancer(serviceB.URL, false)
+	if err != nil {
+		b.Fatalf("ParseBalancer: %v", err)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	defer transport.CloseIdleConnections()
+	h := NewHandler(balancer, transport, 0)
+
+	var reused int
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				reused++
+			}
+		},
+	})
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := h.callServiceB(ctx, "01001000", nil); err != nil {
+			b.Fatalf("callServiceB: %v", err)
+		}
+	}
+	b.StopTimer()
+
+	if reused != b.N-1 {
+		b.Errorf("reused %d of %d connections, want every call after the first", reused, b.N)
+	}
+	if got := missingTraceparent.Load(); got != 0 {
+		b.Errorf("%d requests reached service B without a traceparent header", got)
+	}
+}
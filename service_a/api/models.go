@@ -6,11 +6,39 @@ type CEPRequest struct {
 
 type ErrorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
 }
 
 type WeatherResponse struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	CEP       string   `json:"cep,omitempty"`
+	City      string   `json:"city"`
+	TempC     *float64 `json:"temp_C,omitempty"`
+	TempF     *float64 `json:"temp_F,omitempty"`
+	TempK     *float64 `json:"temp_K,omitempty"`
+	TempR     *float64 `json:"temp_R,omitempty"`
+	TempRe    *float64 `json:"temp_Re,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+	Degraded  bool     `json:"degraded,omitempty"`
+	Stale     bool     `json:"stale,omitempty"`
+	IBGE      string   `json:"ibge,omitempty"`
+	DDD       string   `json:"ddd,omitempty"`
+	UTCOffset string   `json:"utc_offset,omitempty"`
+	TraceURL  string   `json:"trace_url,omitempty"`
+	FetchedAt string   `json:"fetched_at,omitempty"`
+	Lat       *float64 `json:"lat,omitempty"`
+	Lon       *float64 `json:"lon,omitempty"`
+
+	Formatted *FormattedTemperatures `json:"formatted,omitempty"`
+	Debug     *DebugInfo             `json:"debug,omitempty"`
+	Cache     string                 `json:"-"`
+}
+
+type FormattedTemperatures struct {
+	Celsius    string `json:"celsius"`
+	Fahrenheit string `json:"fahrenheit"`
+	Kelvin     string `json:"kelvin"`
+}
+
+type DebugInfo struct {
+	UpstreamRequests []string `json:"upstream_requests"`
 }
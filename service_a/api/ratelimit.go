@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+const (
+	DefaultRateLimitBurst           = 10
+	DefaultRateLimitIdleTTL         = 10 * time.Minute
+	DefaultRateLimitCleanupInterval = time.Minute
+)
+
+type LimiterStore interface {
+	Allow(key string) (bool, time.Duration)
+}
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+type MemoryLimiterStore struct {
+	rps     rate.Limit
+	burst   int
+	idleTTL time.Duration
+
+	mu       sync.Mutex
+	limiters map[string]*limiterEntry
+	now      func() time.Time
+}
+
+func NewMemoryLimiterStore(rps float64, burst int, idleTTL time.Duration) *MemoryLimiterStore {
+	return &MemoryLimiterStore{
+		rps:      rate.Limit(rps),
+		burst:    burst,
+		idleTTL:  idleTTL,
+		limiters: map[string]*limiterEntry{},
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryLimiterStore) Allow(key string) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	entry, ok := s.limiters[key]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(s.rps, s.burst)}
+		s.limiters[key] = entry
+	}
+	entry.lastSeen = now
+
+	reservation := entry.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (s *MemoryLimiterStore) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	cutoff := s.now().Add(-s.idleTTL)
+	for key, entry := range s.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(s.limiters, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (s *MemoryLimiterStore) StartCleanup(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Cleanup()
+			}
+		}
+	}()
+}
+
+func RateLimit(store LimiterStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter := store.Allow(clientIP(r))
+			if !allowed {
+				trace.SpanFromContext(r.Context()).SetAttributes(attribute.Bool("ratelimit.limited", true))
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, int(math.Ceil(retryAfter.Seconds())))))
+				WriteErrorCode(w, "too many requests", "RATE_LIMITED", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRejectsRequestOverBurst(t *testing.T) {
+	const burst = 3
+	store := NewMemoryLimiterStore(1, burst, time.Minute)
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+
+	handler := RateLimit(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/service-a?cep=01001000", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := range burst {
+		if rec := send("192.0.2.1:1234"); rec.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i+1, rec.Code)
+		}
+	}
+
+	rec := send("192.0.2.1:1234")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request %d status = %d, want 429", burst+1, rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Code != "RATE_LIMITED" {
+		t.Errorf("body = %+v (%v), want RATE_LIMITED", resp, err)
+	}
+
+	if rec := send("198.51.100.7:1234"); rec.Code != http.StatusOK {
+		t.Errorf("other client status = %d, want its own bucket", rec.Code)
+	}
+
+	now = now.Add(time.Second)
+	if rec := send("192.0.2.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("status after refill = %d, want 200", rec.Code)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if removed := store.Cleanup(); removed != 2 {
+		t.Errorf("Cleanup() removed %d idle clients, want 2", removed)
+	}
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+func (h *Handler) CheckServiceB(ctx context.Context) error {
+	var errs []error
+	for _, backendURL := range h.ServiceB.Backends() {
+		err := h.checkBackend(ctx, backendURL)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", backendURL, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (h *Handler) checkBackend(ctx context.Context, backendURL string) error {
+	healthURL, err := url.Parse(backendURL)
+	if err != nil {
+		return err
+	}
+	healthURL.Path = "/healthz"
+	healthURL.RawQuery = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := (&http.Client{Transport: h.Transport}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("service-b healthz returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckServiceBProbesEveryBackend(t *testing.T) {
+	var downHits, upHits atomic.Int32
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downHits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer up.Close()
+
+	balancer, err := ParseBalancer(down.URL+"|2,"+up.URL, false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	h := NewHandler(balancer, http.DefaultTransport, 0)
+
+	for range 3 {
+		if err := h.CheckServiceB(context.Background()); err != nil {
+			t.Fatalf("CheckServiceB() = %v, want nil while one backend is healthy", err)
+		}
+	}
+	if downHits.Load() != 3 || upHits.Load() != 3 {
+		t.Errorf("hits = down:%d up:%d, want 3 each", downHits.Load(), upHits.Load())
+	}
+
+	want := []string{down.URL, up.URL, down.URL}
+	for i, url := range want {
+		if got := balancer.Next(); got != url {
+			t.Errorf("Next() #%d = %q, want %q; probes must not advance the rotation", i, got, url)
+		}
+	}
+
+	up.Close()
+	if err := h.CheckServiceB(context.Background()); err == nil {
+		t.Error("CheckServiceB() = nil, want error once every backend is down")
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ReplayRequest struct {
+	CEP          string `json:"cep"`
+	IncludeCodes bool   `json:"include_codes,omitempty"`
+}
+
+const (
+	ReplayStepValidate = "validate"
+	ReplayStepServiceB = "service-b"
+
+	ReplayOutcomeOK    = "ok"
+	ReplayOutcomeError = "error"
+)
+
+type ReplayStep struct {
+	Name             string   `json:"name"`
+	Outcome          string   `json:"outcome"`
+	Status           int      `json:"status,omitempty"`
+	Code             string   `json:"code,omitempty"`
+	Message          string   `json:"message,omitempty"`
+	DurationMs       int64    `json:"duration_ms"`
+	UpstreamRequests []string `json:"upstream_requests,omitempty"`
+}
+
+type ReplayResponse struct {
+	CEP        string           `json:"cep"`
+	TraceID    string           `json:"trace_id"`
+	DurationMs int64            `json:"duration_ms"`
+	Status     int              `json:"status"`
+	Code       string           `json:"code,omitempty"`
+	Weather    *WeatherResponse `json:"weather,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Steps      []ReplayStep     `json:"steps"`
+}
+
+func (h *Handler) HandleReplay(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("service-a")
+	ctx, span := tracer.Start(r.Context(), "service-a: admin-replay", trace.WithNewRoot())
+	defer span.End()
+
+	var req ReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid request body")
+		WriteError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	cep, err := h.validateCEP(ctx, req.CEP)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		WriteError(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	req.CEP = cep
+	steps := []ReplayStep{{Name: ReplayStepValidate, Outcome: ReplayOutcomeOK, DurationMs: time.Since(start).Milliseconds()}}
+
+	span.SetAttributes(attribute.String("cep", req.CEP), attribute.Bool("replay", true))
+
+	forwarded := url.Values{}
+	if req.IncludeCodes {
+		forwarded.Set("include_codes", "true")
+	}
+
+	callStart := time.Now()
+	weather, err := h.callServiceB(ctx, req.CEP, forwarded)
+	step := ReplayStep{Name: ReplayStepServiceB, Outcome: ReplayOutcomeOK, Status: http.StatusOK, DurationMs: time.Since(callStart).Milliseconds()}
+
+	resp := ReplayResponse{
+		CEP:     req.CEP,
+		TraceID: span.SpanContext().TraceID().String(),
+		Status:  http.StatusOK,
+		Weather: weather,
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "replay failed")
+		errResp, status := serviceBErrorResponse(err)
+		step.Outcome, step.Status, step.Code, step.Message = ReplayOutcomeError, status, errResp.Code, err.Error()
+		resp.Status, resp.Code, resp.Error = status, errResp.Code, errResp.Message
+	} else {
+		if weather.Debug != nil {
+			step.UpstreamRequests = weather.Debug.UpstreamRequests
+		}
+		span.SetStatus(codes.Ok, "")
+	}
+	resp.Steps = append(steps, step)
+	resp.DurationMs = time.Since(start).Milliseconds()
+
+	WriteJSON(w, resp, http.StatusOK)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestHandleReplayDiagnostics(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantOutcome string
+	}{
+		{"cep not found", http.StatusNotFound, `{"message":"can not find zipcode"}`, http.StatusNotFound, "", ReplayOutcomeError},
+		{"temperature unavailable", http.StatusServiceUnavailable, `{"message":"temperature not available","code":"TEMPERATURE_UNAVAILABLE"}`, http.StatusServiceUnavailable, "TEMPERATURE_UNAVAILABLE", ReplayOutcomeError},
+		{"ok", http.StatusOK, `{"city":"Sao Paulo","debug":{"upstream_requests":["http://viacep.test/ws/01001000/json/"]}}`, http.StatusOK, "", ReplayOutcomeOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer serviceB.Close()
+
+			balancer, err := ParseBalancer(serviceB.URL, false)
+			if err != nil {
+				t.Fatalf("ParseBalancer: %v", err)
+			}
+			h := NewHandler(balancer, http.DefaultTransport, 0)
+			h.AdminToken = "secret"
+			router := SetupRouter(h, httpx.Config{Timeout: 5 * time.Second})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/replay", strings.NewReader(`{"cep":"01001-000"}`))
+			req.Header.Set("Authorization", "Bearer secret")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("replay status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var resp ReplayResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.CEP != "01001000" || resp.Status != tt.wantStatus || resp.Code != tt.wantCode {
+				t.Errorf("replay = cep %q status %d code %q, want 01001000 %d %q", resp.CEP, resp.Status, resp.Code, tt.wantStatus, tt.wantCode)
+			}
+			if len(resp.Steps) != 2 || resp.Steps[0].Name != ReplayStepValidate || resp.Steps[1].Name != ReplayStepServiceB {
+				t.Fatalf("steps = %+v, want validate then service-b", resp.Steps)
+			}
+			got := resp.Steps[1]
+			if got.Outcome != tt.wantOutcome || got.Status != tt.wantStatus || got.Code != tt.wantCode {
+				t.Errorf("service-b step = %+v, want outcome %q status %d code %q", got, tt.wantOutcome, tt.wantStatus, tt.wantCode)
+			}
+			if tt.wantOutcome == ReplayOutcomeOK && len(got.UpstreamRequests) != 1 {
+				t.Errorf("upstream_requests = %v, want service B's debug list", got.UpstreamRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import "testing"
+
+func TestNormalizeCEP(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{"plain digits", "01310100", "01310100", true},
+		{"dash", "01310-100", "01310100", true},
+		{"spaces", " 01310 100 ", "01310100", true},
+		{"dots and dash", "01.310-100", "01310100", true},
+		{"letters", "0131O-100", "", false},
+		{"too short", "01310-10", "0131010", false},
+		{"too long", "01310-1000", "013101000", false},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeCEP(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("NormalizeCEP(%q) ok = %v, want %v", tt.input, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("NormalizeCEP(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	serviceBStatusPath    = "/admin/status"
+	serviceBStatusTimeout = 2 * time.Second
+)
+
+type AggregatedStatus struct {
+	Status   string                `json:"status"`
+	Services []httpx.ServiceStatus `json:"services"`
+}
+
+func (h *Handler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	services := []httpx.ServiceStatus{httpx.NewServiceStatus("service-a", h.StartedAt)}
+	serviceB := h.fetchServiceBStatuses(r.Context())
+
+	overall := httpx.StatusOK
+	for _, status := range serviceB {
+		if status.Status != httpx.StatusOK {
+			overall = httpx.StatusDegraded
+		}
+	}
+
+	httpx.WriteStatus(w, AggregatedStatus{
+		Status:   overall,
+		Services: append(services, serviceB...),
+	}, overall)
+}
+
+func (h *Handler) fetchServiceBStatuses(ctx context.Context) []httpx.ServiceStatus {
+	backends := h.ServiceB.Backends()
+	statuses := make([]httpx.ServiceStatus, len(backends))
+
+	var wg sync.WaitGroup
+	for i, backendURL := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = h.fetchServiceBStatus(ctx, backendURL)
+		}()
+	}
+	wg.Wait()
+	return statuses
+}
+
+func (h *Handler) fetchServiceBStatus(ctx context.Context, backendURL string) httpx.ServiceStatus {
+	tracer := otel.Tracer("service-a")
+	ctx, span := tracer.Start(ctx, "service-a: fetch-service-b-status")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, serviceBStatusTimeout)
+	defer cancel()
+
+	status := httpx.ServiceStatus{Service: "service-b", Status: httpx.StatusDown, Details: map[string]any{"url": backendURL}}
+
+	statusURL, err := url.Parse(backendURL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid service-b url")
+		status.Error = err.Error()
+		return status
+	}
+	statusURL.Path = serviceBStatusPath
+	statusURL.RawQuery = ""
+	span.SetAttributes(attribute.String("service_b.status_url", statusURL.String()))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL.String(), nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		status.Error = err.Error()
+		return status
+	}
+	if h.ServiceBAdminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.ServiceBAdminToken)
+	}
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to call service-b")
+		status.Error = err.Error()
+		return status
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("service-b status returned %d", resp.StatusCode)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "unexpected status from service-b")
+		status.Error = err.Error()
+		return status
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to decode response")
+		return httpx.ServiceStatus{Service: "service-b", Status: httpx.StatusDown, Details: map[string]any{"url": backendURL}, Error: err.Error()}
+	}
+	if status.Details == nil {
+		status.Details = map[string]any{}
+	}
+	status.Details["url"] = backendURL
+
+	span.SetStatus(codes.Ok, "")
+	return status
+}
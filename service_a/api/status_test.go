@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestStatusHandlerReportsEveryBackend(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpx.WriteStatus(w, httpx.ServiceStatus{Service: "service-b", Status: httpx.StatusOK}, httpx.StatusOK)
+	}))
+	defer healthy.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	balancer, err := ParseBalancer(healthy.URL+","+broken.URL, false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	h := NewHandler(balancer, http.DefaultTransport, 0)
+
+	rec := httptest.NewRecorder()
+	h.StatusHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/status", nil))
+
+	var resp AggregatedStatus
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Status != httpx.StatusDegraded {
+		t.Errorf("status = %q, want %q", resp.Status, httpx.StatusDegraded)
+	}
+	if len(resp.Services) != 3 {
+		t.Fatalf("services = %d, want service-a plus two service-b backends", len(resp.Services))
+	}
+	for i, want := range []struct{ url, status string }{{healthy.URL, httpx.StatusOK}, {broken.URL, httpx.StatusDown}} {
+		got := resp.Services[i+1]
+		if got.Details["url"] != want.url || got.Status != want.status {
+			t.Errorf("services[%d] = %v %q, want %s %q", i+1, got.Details["url"], got.Status, want.url, want.status)
+		}
+	}
+}
+
+func TestStatusHandlerSendsServiceBAdminToken(t *testing.T) {
+	serviceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer b-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		httpx.WriteStatus(w, httpx.ServiceStatus{Service: "service-b", Status: httpx.StatusOK}, httpx.StatusOK)
+	}))
+	defer serviceB.Close()
+
+	balancer, err := ParseBalancer(serviceB.URL, false)
+	if err != nil {
+		t.Fatalf("ParseBalancer: %v", err)
+	}
+	h := NewHandler(balancer, http.DefaultTransport, 0)
+	h.ServiceBAdminToken = "b-token"
+
+	rec := httptest.NewRecorder()
+	h.StatusHandler(rec, httptest.NewRequest(http.MethodGet, "/admin/status", nil))
+
+	var resp AggregatedStatus
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Status != httpx.StatusOK {
+		t.Errorf("status = %q, want %q: %+v", resp.Status, httpx.StatusOK, resp.Services)
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestServiceBTimeout(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	release := make(chan struct{})
+	h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(time.Second):
+		}
+		w.Write([]byte(`{"city":"Sao Paulo"}`))
+	})
+	defer close(release)
+	h.RequestTimeout = 20 * time.Millisecond
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/service-a?cep=01001000", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504: %s", rec.Code, rec.Body)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Code != "DOWNSTREAM_TIMEOUT" {
+		t.Errorf("code = %q, want DOWNSTREAM_TIMEOUT", resp.Code)
+	}
+
+	var metrics metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if got := serviceBCallCount(metrics, "downstream_timeout"); got != 1 {
+		t.Errorf("service_b.calls{result=downstream_timeout} = %d, want 1", got)
+	}
+}
+
+func serviceBCallCount(metrics metricdata.ResourceMetrics, result string) int64 {
+	var total int64
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != "service_b.calls" || !ok {
+				continue
+			}
+			for _, point := range sum.DataPoints {
+				if value, _ := point.Attributes.Value(attribute.Key("result")); value.AsString() == result {
+					total += point.Value
+				}
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const DefaultMaxValidateSize = 1000
+
+type ValidateItemResult struct {
+	CEP        string `json:"cep"`
+	Valid      bool   `json:"valid"`
+	Normalized string `json:"normalized,omitempty"`
+}
+
+type ValidateResponse struct {
+	Results []ValidateItemResult `json:"results"`
+}
+
+func (h *Handler) HandleValidate(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("service-a")
+	_, span := tracer.Start(r.Context(), "service-a: handle-validate")
+	defer span.End()
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid request body")
+		WriteError(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.CEPs) == 0 || len(req.CEPs) > h.MaxValidateSize {
+		span.SetStatus(codes.Error, "invalid validate size")
+		WriteError(w, fmt.Sprintf("ceps must contain between 1 and %d items", h.MaxValidateSize), http.StatusBadRequest)
+		return
+	}
+
+	results := make([]ValidateItemResult, len(req.CEPs))
+	valid := 0
+	for i, cep := range req.CEPs {
+		results[i].CEP = cep
+		if normalized, ok := NormalizeCEP(cep); ok && h.cepInRange(normalized) {
+			results[i].Valid = true
+			results[i].Normalized = normalized
+			valid++
+		}
+	}
+
+	span.SetAttributes(attribute.Int("validate.size", len(req.CEPs)), attribute.Int("validate.valid", valid))
+	span.SetStatus(codes.Ok, "")
+	WriteJSON(w, ValidateResponse{Results: results}, http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHandleValidateMixedCEPs(t *testing.T) {
+	var calls atomic.Int32
+	h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+
+	body := `{"ceps":["01001000","01001-000","1234567","abcdefgh"," 20040002 "]}`
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/service-a/validate", strings.NewReader(body)))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp ValidateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []ValidateItemResult{
+		{CEP: "01001000", Valid: true, Normalized: "01001000"},
+		{CEP: "01001-000", Valid: true, Normalized: "01001000"},
+		{CEP: "1234567"},
+		{CEP: "abcdefgh"},
+		{CEP: " 20040002 ", Valid: true, Normalized: "20040002"},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("results = %+v, want %+v", resp.Results, want)
+	}
+	for i := range want {
+		if resp.Results[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, resp.Results[i], want[i])
+		}
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("service-b calls = %d, want none", got)
+	}
+}
+
+func TestHandleValidateRejectsBadSizes(t *testing.T) {
+	h := newStubbedHandler(t, func(w http.ResponseWriter, r *http.Request) {})
+	h.MaxValidateSize = 2
+
+	for _, body := range []string{`{"ceps":[]}`, `{"ceps":["01001000","01001000","01001000"]}`, `not json`} {
+		rec := serve(h, httptest.NewRequest(http.MethodPost, "/service-a/validate", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s status = %d, want 400", body, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_a/api"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+type Config struct {
+	Port         string
+	ServiceB     *api.Balancer
+	RequireHTTPS bool
+
+	TracerShutdownTimeout  time.Duration
+	MetricsExportInterval  time.Duration
+	RuntimeMetricsInterval time.Duration
+
+	DialNetwork      string
+	ConnectTimeout   time.Duration
+	MaxConnsPerHost  int
+	SigningSecret    string
+	MaxRedirects     int
+	ServiceBTimeout  time.Duration
+	BatchTimeout     time.Duration
+	BatchItemTimeout time.Duration
+	MaxBatchSize     int
+	MaxValidateSize  int
+
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	RateLimitRPS     float64
+	RateLimitBurst   int
+
+	AdminToken         string
+	ServiceBAdminToken string
+	Debug              bool
+	TraceURLTemplate   string
+	StrictCEPRanges    bool
+
+	ReadinessGrace      time.Duration
+	DrainDelay          time.Duration
+	ServerID            string
+	MaxHops             int
+	CompressionLevel    int
+	MaintenanceMode     bool
+	RequestIDHeader     string
+	TenantHeader        string
+	MaxDecompressedBody int
+}
+
+func loadConfig() (Config, error) {
+	env := &utils.EnvReader{}
+
+	cfg := Config{
+		Port:         env.String("PORT", defaultPort),
+		RequireHTTPS: env.Bool("SERVICE_B_REQUIRE_HTTPS"),
+
+		TracerShutdownTimeout:  env.Duration("OTEL_SHUTDOWN_TIMEOUT", utils.DefaultTracerShutdownTimeout),
+		MetricsExportInterval:  env.Duration("METRICS_EXPORT_INTERVAL", utils.DefaultMetricsExportInterval),
+		RuntimeMetricsInterval: env.Duration("RUNTIME_METRICS_INTERVAL", utils.DefaultRuntimeMetricsInterval),
+
+		DialNetwork:      os.Getenv("DIAL_NETWORK"),
+		ConnectTimeout:   env.Duration("UPSTREAM_CONNECT_TIMEOUT", httpx.DefaultConnectTimeout),
+		MaxConnsPerHost:  env.Int("UPSTREAM_MAX_CONNS_PER_HOST", 0),
+		SigningSecret:    os.Getenv("OUTBOUND_SIGNING_SECRET"),
+		MaxRedirects:     env.Int("MAX_REDIRECTS", defaultMaxRedirect),
+		BatchTimeout:     env.Duration("BATCH_TIMEOUT", api.DefaultBatchTimeout),
+		BatchItemTimeout: env.Duration("BATCH_ITEM_TIMEOUT", api.DefaultBatchItemTimeout),
+		MaxBatchSize:     env.Int("MAX_BATCH_SIZE", api.DefaultMaxBatchSize),
+		MaxValidateSize:  env.Int("MAX_VALIDATE_SIZE", api.DefaultMaxValidateSize),
+
+		BreakerThreshold: env.Int("CIRCUIT_BREAKER_THRESHOLD", api.DefaultBreakerThreshold),
+		BreakerCooldown:  env.Duration("CIRCUIT_BREAKER_COOLDOWN", api.DefaultBreakerCooldown),
+		RateLimitRPS:     env.Float("RATE_LIMIT_RPS", 0),
+		RateLimitBurst:   env.Int("RATE_LIMIT_BURST", api.DefaultRateLimitBurst),
+
+		AdminToken:         os.Getenv("ADMIN_TOKEN"),
+		ServiceBAdminToken: os.Getenv("SERVICE_B_ADMIN_TOKEN"),
+		Debug:              env.Bool("DEBUG_MODE"),
+		StrictCEPRanges:    env.Bool("STRICT_CEP_RANGES"),
+
+		ReadinessGrace:      env.Duration("READINESS_GRACE_PERIOD", 0),
+		DrainDelay:          env.Duration("SHUTDOWN_DRAIN_DELAY", 0),
+		ServerID:            httpx.ResolveServerID(os.Getenv("SERVER_ID")),
+		MaxHops:             env.Int("MAX_HOPS", defaultMaxHops),
+		CompressionLevel:    env.Int("COMPRESSION_LEVEL", httpx.DefaultCompressionLevel),
+		MaintenanceMode:     env.Bool("MAINTENANCE_MODE"),
+		RequestIDHeader:     os.Getenv("REQUEST_ID_HEADER"),
+		TenantHeader:        os.Getenv("TENANT_HEADER"),
+		MaxDecompressedBody: env.Int("MAX_DECOMPRESSED_BODY_BYTES", httpx.DefaultMaxDecompressedBody),
+	}
+	if cfg.Debug {
+		cfg.TraceURLTemplate = os.Getenv("TRACE_URL_TEMPLATE")
+	}
+	cfg.ServiceBTimeout = env.Duration("SERVICE_B_TIMEOUT", env.Duration("UPSTREAM_REQUEST_TIMEOUT", httpx.DefaultRequestTimeout))
+
+	serviceBURLs := env.String("SERVICE_B_URLS", os.Getenv("SERVICE_B_URL"))
+	if serviceBURLs == "" {
+		env.Fail(errors.New("SERVICE_B_URL or SERVICE_B_URLS environment variable not set"))
+	} else {
+		var err error
+		cfg.ServiceB, err = api.ParseBalancer(serviceBURLs, cfg.RequireHTTPS)
+		env.Check("SERVICE_B_URLS", err)
+	}
+
+	switch cfg.DialNetwork {
+	case "", httpx.DialNetworkTCP, httpx.DialNetworkTCP4, httpx.DialNetworkTCP6:
+	default:
+		env.Fail(fmt.Errorf("DIAL_NETWORK %q must be one of tcp, tcp4 or tcp6", cfg.DialNetwork))
+	}
+	if cfg.BatchTimeout >= requestTimeout {
+		env.Fail(fmt.Errorf("BATCH_TIMEOUT must be shorter than the %s request timeout, got %s", requestTimeout, cfg.BatchTimeout))
+	}
+	if cfg.CompressionLevel < 1 || cfg.CompressionLevel > 9 {
+		env.Fail(fmt.Errorf("COMPRESSION_LEVEL must be between 1 and 9, got %d", cfg.CompressionLevel))
+	}
+	if cfg.RateLimitRPS < 0 {
+		env.Fail(fmt.Errorf("RATE_LIMIT_RPS must not be negative, got %g", cfg.RateLimitRPS))
+	}
+	for _, setting := range []struct {
+		key   string
+		value int
+	}{
+		{"RATE_LIMIT_BURST", cfg.RateLimitBurst},
+		{"MAX_BATCH_SIZE", cfg.MaxBatchSize},
+		{"MAX_VALIDATE_SIZE", cfg.MaxValidateSize},
+		{"CIRCUIT_BREAKER_THRESHOLD", cfg.BreakerThreshold},
+	} {
+		if setting.value < 1 {
+			env.Fail(fmt.Errorf("%s must be at least 1, got %d", setting.key, setting.value))
+		}
+	}
+	if cfg.MaxHops < 0 {
+		env.Fail(fmt.Errorf("MAX_HOPS must not be negative, got %d", cfg.MaxHops))
+	}
+
+	return cfg, env.Err()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigRejectsMalformedEnv(t *testing.T) {
+	t.Setenv("SERVICE_B_URL", "http://service-b:8081/weather")
+	t.Setenv("MAX_HOPS", "abc")
+	t.Setenv("RATE_LIMIT_BURST", "0")
+	t.Setenv("SERVICE_B_TIMEOUT", "5mins")
+	t.Setenv("DEBUG_MODE", "maybe")
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("loadConfig() = nil, want error for malformed values")
+	}
+	for _, key := range []string{"MAX_HOPS", "RATE_LIMIT_BURST", "SERVICE_B_TIMEOUT", "DEBUG_MODE"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not mention %s", err, key)
+		}
+	}
+}
+
+func TestLoadConfigRequiresServiceB(t *testing.T) {
+	t.Setenv("SERVICE_B_URL", "")
+	t.Setenv("SERVICE_B_URLS", "")
+
+	if _, err := loadConfig(); err == nil || !strings.Contains(err.Error(), "SERVICE_B_URL") {
+		t.Errorf("loadConfig() = %v, want a missing SERVICE_B_URL error", err)
+	}
+}
+
+func TestLoadConfigParsesValidEnv(t *testing.T) {
+	t.Setenv("SERVICE_B_URLS", "service-b-1:8081/weather|2,service-b-2:8081/weather")
+	t.Setenv("MAX_HOPS", "3")
+	t.Setenv("RATE_LIMIT_RPS", "2.5")
+	t.Setenv("RATE_LIMIT_BURST", "4")
+	t.Setenv("SERVICE_B_TIMEOUT", "2s")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() = %v", err)
+	}
+	if cfg.MaxHops != 3 || cfg.RateLimitRPS != 2.5 || cfg.RateLimitBurst != 4 || cfg.ServiceBTimeout != 2*time.Second {
+		t.Errorf("cfg = %+v, want values from the environment", cfg)
+	}
+	if got := len(cfg.ServiceB.Backends()); got != 2 {
+		t.Errorf("service-b backends = %d, want 2", got)
+	}
+	if cfg.Port != defaultPort {
+		t.Errorf("Port = %q, want default %q", cfg.Port, defaultPort)
+	}
+}
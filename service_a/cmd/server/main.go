@@ -11,6 +11,7 @@ import (
 
 	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_a/api"
 	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
 )
 
 const (
@@ -19,39 +20,80 @@ const (
 	serverReadTimeout  = 10 * time.Second
 	serverWriteTimeout = 10 * time.Second
 	serverIdleTimeout  = 60 * time.Second
+	requestTimeout     = 9 * time.Second
+	defaultMaxHops     = 5
+	defaultMaxRedirect = 3
 )
 
 func main() {
-	otelEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if otelEndpoint == "" {
-		log.Panic("OTEL_EXPORTER_OTLP_ENDPOINT environment variable not set")
+	logLevel, err := utils.InitLogger(os.Stdout, "service-a", os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		log.Fatalf("Invalid LOG_LEVEL: %v", err)
 	}
 
-	shutdownTracer, err := utils.InitTracer("service-a", otelEndpoint)
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	_, shutdownTracer, err := utils.InitTracerProvider(context.Background(), "service-a")
 	if err != nil {
 		log.Fatalf("Failed to initialize tracer: %v", err)
 	}
-	defer func() {
-		if err := shutdownTracer(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer: %v", err)
-		}
-	}()
 
-	serviceBURL := os.Getenv("SERVICE_B_URL")
-	if serviceBURL == "" {
-		log.Panic("SERVICE_B_URL environment variable not set")
+	meterProvider, shutdownMeter, err := utils.InitMeterProvider(context.Background(), "service-a", cfg.MetricsExportInterval)
+	if err != nil {
+		log.Fatalf("Failed to initialize meter provider: %v", err)
+	}
+
+	metricsCtx, stopMetrics := context.WithCancel(context.Background())
+	defer stopMetrics()
+	if err := utils.StartRuntimeMetrics(metricsCtx, meterProvider, cfg.RuntimeMetricsInterval); err != nil {
+		log.Printf("Failed to start runtime metrics: %v", err)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	transport, err := httpx.NewTransport(cfg.DialNetwork, cfg.ConnectTimeout, true)
+	if err != nil {
+		log.Fatalf("Failed to create service-b transport: %v", err)
+	}
+	httpx.LimitConnsPerHost(transport, cfg.MaxConnsPerHost)
+
+	handler := api.NewHandler(cfg.ServiceB, httpx.NewSigningTransport(transport, cfg.SigningSecret), cfg.MaxRedirects)
+	handler.Readiness = httpx.NewReadiness(cfg.ReadinessGrace)
+	handler.Readiness.AddCheck("service-b", handler.CheckServiceB)
+	handler.AdminToken = cfg.AdminToken
+	handler.ServiceBAdminToken = cfg.ServiceBAdminToken
+	handler.LogLevel = logLevel
+	handler.Debug = cfg.Debug
+	handler.TraceURLTemplate = cfg.TraceURLTemplate
+	handler.StrictCEPRanges = cfg.StrictCEPRanges
+	handler.RequestTimeout = cfg.ServiceBTimeout
+	handler.BatchTimeout = cfg.BatchTimeout
+	handler.BatchItemTimeout = cfg.BatchItemTimeout
+	handler.MaxBatchSize = cfg.MaxBatchSize
+	handler.MaxValidateSize = cfg.MaxValidateSize
+	handler.Breaker.Threshold = cfg.BreakerThreshold
+	handler.Breaker.Cooldown = cfg.BreakerCooldown
+	if cfg.RateLimitRPS > 0 {
+		limiter := api.NewMemoryLimiterStore(cfg.RateLimitRPS, cfg.RateLimitBurst, api.DefaultRateLimitIdleTTL)
+		limiter.StartCleanup(metricsCtx, api.DefaultRateLimitCleanupInterval)
+		handler.RateLimiter = limiter
 	}
 
-	handler := api.NewHandler(serviceBURL)
-	router := api.SetupRouter(handler)
+	router := api.SetupRouter(handler, httpx.Config{
+		ServerID:         cfg.ServerID,
+		Timeout:          requestTimeout,
+		MaxHops:          cfg.MaxHops,
+		CompressionLevel: cfg.CompressionLevel,
+		MaintenanceMode:  cfg.MaintenanceMode,
+		RequestIDHeader:  cfg.RequestIDHeader,
+		TenantHeader:     cfg.TenantHeader,
+
+		MaxDecompressedBody: int64(cfg.MaxDecompressedBody),
+	})
 
 	server := &http.Server{
-		Addr:         ":" + port,
+		Addr:         ":" + cfg.Port,
 		Handler:      router,
 		ReadTimeout:  serverReadTimeout,
 		WriteTimeout: serverWriteTimeout,
@@ -61,7 +103,7 @@ func main() {
 	serverErrors := make(chan error, 1)
 
 	go func() {
-		log.Printf("Service A starting on port %s", port)
+		log.Printf("Service A starting on port %s", cfg.Port)
 		serverErrors <- server.ListenAndServe()
 	}()
 
@@ -74,6 +116,12 @@ func main() {
 	case sig := <-shutdown:
 		log.Printf("Received signal %v, shutting down gracefully...", sig)
 
+		handler.Readiness.MarkShuttingDown()
+		if cfg.DrainDelay > 0 {
+			log.Printf("Readiness marked down, waiting %s before draining connections", cfg.DrainDelay)
+			time.Sleep(cfg.DrainDelay)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 
@@ -82,6 +130,17 @@ func main() {
 			server.Close()
 		}
 
+		flushTimeout := cfg.TracerShutdownTimeout
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < flushTimeout {
+			flushTimeout = time.Until(deadline)
+		}
+		if err := utils.ShutdownTracer(shutdownTracer, flushTimeout); err != nil {
+			log.Printf("Error shutting down tracer: %v", err)
+		}
+		if err := shutdownMeter(ctx); err != nil {
+			log.Printf("Error shutting down meter provider: %v", err)
+		}
+
 		log.Println("Service A stopped")
 	}
 }
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+const weatherAPIKeyHeader = "X-Weatherapi-Key"
+
+var apiKeyParamRegex = regexp.MustCompile(`([?&]key=)[^&\s"]*`)
+
+func redactKey(rawURL string) string {
+	return apiKeyParamRegex.ReplaceAllString(rawURL, "${1}REDACTED")
+}
+
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactKey(urlErr.URL)
+	}
+	return err
+}
+
+type apiKeyTransport struct {
+	next http.RoundTripper
+}
+
+func NewAPIKeyTransport(next http.RoundTripper) http.RoundTripper {
+	return &apiKeyTransport{next: next}
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.Header.Get(weatherAPIKeyHeader)
+	if key == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	keyed := req.Clone(req.Context())
+	keyed.Header.Del(weatherAPIKeyHeader)
+	query := keyed.URL.Query()
+	query.Set("key", key)
+	keyed.URL.RawQuery = query.Encode()
+
+	resp, err := t.next.RoundTrip(keyed)
+	return resp, redactError(err)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const secretAPIKey = "secret-weather-key"
+
+func TestAPIKeyNeverRecorded(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, r *http.Request)
+	}{
+		{"client error echoing the url", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error":"bad request to %s"}`, r.URL)
+		}},
+		{"server error echoing the url", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+			fmt.Fprintf(w, `{"error":"upstream failed for %s"}`, r.URL)
+		}},
+		{"connection dropped", func(w http.ResponseWriter, r *http.Request) {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			var logs bytes.Buffer
+			previous := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+			t.Cleanup(func() { slog.SetDefault(previous) })
+
+			weatherAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("key") != secretAPIKey {
+					t.Errorf("WeatherAPI got key %q, want it in the query", r.URL.Query().Get("key"))
+				}
+				tt.respond(w, r)
+			}))
+			defer weatherAPI.Close()
+
+			client := &http.Client{Transport: otelhttp.NewTransport(NewAPIKeyTransport(http.DefaultTransport))}
+			h := NewHandler(secretAPIKey, client, "pt")
+			h.WeatherAPIBaseURL = weatherAPI.URL
+			h.MaxRetries = 0
+			h.Debug = true
+			h.CEPOverrides = map[string]string{"01001000": "Sao Paulo"}
+			h.ErrorLog = httpx.NewErrorLog(10)
+
+			rec := httptest.NewRecorder()
+			SetupRouter(h, httpx.Config{Timeout: 5 * time.Second}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/weather?cep=01001000", nil))
+
+			if strings.Contains(rec.Body.String(), secretAPIKey) {
+				t.Errorf("response leaks the api key: %s", rec.Body)
+			}
+			if strings.Contains(logs.String(), secretAPIKey) {
+				t.Errorf("logs leak the api key:\n%s", logs.String())
+			}
+			for _, span := range recorder.Ended() {
+				if dump := dumpSpan(span); strings.Contains(dump, secretAPIKey) {
+					t.Errorf("span %q leaks the api key: %s", span.Name(), dump)
+				}
+			}
+		})
+	}
+}
+
+func dumpSpan(span sdktrace.ReadOnlySpan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %v", span.Name(), span.Status().Description, span.Attributes())
+	for _, event := range span.Events() {
+		fmt.Fprintf(&b, " %s %v", event.Name, event.Attributes)
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrBudgetExceeded = errors.New("upstream call budget exceeded")
+
+type CallBudget interface {
+	Allow(ctx context.Context, upstream string) bool
+}
+
+type SlidingWindowBudget struct {
+	limit  int
+	window time.Duration
+
+	mu          sync.Mutex
+	windowStart time.Time
+	current     int
+	previous    int
+	now         func() time.Time
+}
+
+func NewSlidingWindowBudget(limit int, window time.Duration) *SlidingWindowBudget {
+	return &SlidingWindowBudget{limit: limit, window: window, windowStart: time.Now(), now: time.Now}
+}
+
+func (b *SlidingWindowBudget) Allow(ctx context.Context, upstream string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	elapsed := now.Sub(b.windowStart)
+	if elapsed >= 2*b.window {
+		b.previous, b.current = 0, 0
+		b.windowStart = now
+		elapsed = 0
+	} else if elapsed >= b.window {
+		b.previous, b.current = b.current, 0
+		b.windowStart = b.windowStart.Add(b.window)
+		elapsed -= b.window
+	}
+
+	weight := float64(b.window-elapsed) / float64(b.window)
+	estimated := float64(b.previous)*weight + float64(b.current)
+	if estimated >= float64(b.limit) {
+		trace.SpanFromContext(ctx).AddEvent("budget.exceeded", trace.WithAttributes(
+			attribute.String("budget.upstream", upstream),
+			attribute.Int("budget.limit", b.limit),
+		))
+		return false
+	}
+
+	b.current++
+	return true
+}
+
+type budgetTransport struct {
+	next   http.RoundTripper
+	budget CallBudget
+}
+
+func NewBudgetTransport(next http.RoundTripper, budget CallBudget) http.RoundTripper {
+	return &budgetTransport{next: next, budget: budget}
+}
+
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.budget.Allow(req.Context(), req.URL.Host) {
+		return nil, ErrBudgetExceeded
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestBudgetTransportRejectsCallsPastTheBudget(t *testing.T) {
+	start := time.Unix(0, 0)
+	now := start
+	budget := NewSlidingWindowBudget(3, time.Minute)
+	budget.windowStart = start
+	budget.now = func() time.Time { return now }
+
+	calls := 0
+	transport := NewBudgetTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}), budget)
+
+	call := func() error {
+		req, _ := http.NewRequest(http.MethodGet, "http://api.weatherapi.test/v1/current.json", nil)
+		resp, err := transport.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	for i := range 3 {
+		if err := call(); err != nil {
+			t.Fatalf("call %d: %v", i+1, err)
+		}
+	}
+	if err := call(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("call past the budget = %v, want ErrBudgetExceeded", err)
+	}
+	if calls != 3 {
+		t.Fatalf("upstream calls = %d, want 3", calls)
+	}
+
+	now = start.Add(90 * time.Second)
+	for i := range 2 {
+		if err := call(); err != nil {
+			t.Fatalf("call %d once half the previous window slid out: %v", i+1, err)
+		}
+	}
+	if err := call(); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("call over the sliding estimate = %v, want ErrBudgetExceeded", err)
+	}
+
+	now = start.Add(3 * time.Minute)
+	if err := call(); err != nil {
+		t.Fatalf("call after the window fully reset: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	DefaultCEPCacheTTL     = 24 * time.Hour
+	DefaultWeatherCacheTTL = 10 * time.Minute
+
+	DefaultWeatherCacheStaleTTL = time.Hour
+)
+
+type CacheMetrics struct {
+	cepHits prometheus.Counter
+}
+
+func NewCacheMetrics(registerer prometheus.Registerer) *CacheMetrics {
+	m := &CacheMetrics{
+		cepHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cep_cache_hits_total",
+			Help: "CEP lookups served from the in-memory cache",
+		}),
+	}
+	registerer.MustRegister(m.cepHits)
+	return m
+}
+
+func cacheGet[V any](ctx context.Context, c *cache.TTLCache[V], namespace, key string) (V, bool) {
+	if c == nil {
+		var zero V
+		return zero, false
+	}
+
+	_, span := otel.Tracer("service-b").Start(ctx, "cache.get")
+	defer span.End()
+
+	value, hit := c.Get(key)
+	span.SetAttributes(attribute.String("cache.namespace", namespace), attribute.Bool("cache.hit", hit))
+	httpx.RecordCache(ctx, hit)
+	return value, hit
+}
+
+func cacheSet[V any](ctx context.Context, c *cache.TTLCache[V], namespace, key string, value V) {
+	if c == nil {
+		return
+	}
+
+	_, span := otel.Tracer("service-b").Start(ctx, "cache.set")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("cache.namespace", namespace))
+	c.Set(key, value)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a span recorder as the global tracer provider for the
+// duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestCacheSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	c := cache.NewTTLCache[string](time.Minute)
+	ctx := context.Background()
+
+	cacheGet(ctx, c, "cep", "01001000")
+	cacheSet(ctx, c, "cep", "01001000", "Sao Paulo")
+	cacheGet(ctx, c, "cep", "01001000")
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("recorded %d spans, want 3", len(spans))
+	}
+	tests := []struct {
+		name string
+		hit  string
+	}{
+		{name: "cache.get", hit: "false"},
+		{name: "cache.set"},
+		{name: "cache.get", hit: "true"},
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Name() != tt.name {
+			t.Errorf("span %d = %q, want %q", i, span.Name(), tt.name)
+			continue
+		}
+		if namespace, _ := spanAttribute(span, "cache.namespace"); namespace.AsString() != "cep" {
+			t.Errorf("span %d cache.namespace = %q, want cep", i, namespace.AsString())
+		}
+		hit, ok := spanAttribute(span, "cache.hit")
+		if got := hit.Emit(); ok && got != tt.hit || !ok && tt.hit != "" {
+			t.Errorf("span %d cache.hit = %q (present %v), want %q", i, got, ok, tt.hit)
+		}
+	}
+}
+
+func TestWeatherHandlerCachedCEPSkipsHTTPClient(t *testing.T) {
+	h, client := newStubHandler(stubUpstreams(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`))
+	h.CEPCache = cache.NewTTLCache[ViaCEPResponse](50 * time.Millisecond)
+
+	for range 2 {
+		if rec := serveWeather(h, "cep=01001000"); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+	}
+	if got := len(client.calls("viacep.test")); got != 1 {
+		t.Fatalf("ViaCEP calls within the TTL = %d, want 1", got)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	serveWeather(h, "cep=01001000")
+	if got := len(client.calls("viacep.test")); got != 2 {
+		t.Errorf("ViaCEP calls after the TTL = %d, want 2", got)
+	}
+}
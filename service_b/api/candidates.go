@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const minViaCEPSearchLength = 3
+
+func (h *Handler) writeCandidates(ctx context.Context, w http.ResponseWriter, address *ViaCEPResponse, lang, apiKey string) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: handle-candidates")
+	defer span.End()
+
+	cities, err := h.searchCandidateCities(ctx, address)
+	if err != nil {
+		slog.WarnContext(ctx, "candidate city search failed, using the resolved city only", "city", address.City, "error", err)
+		span.RecordError(err)
+		cities = []string{address.City}
+	}
+
+	resp := CandidatesResponse{Candidates: make([]TempResponse, 0, len(cities))}
+	for _, city := range cities {
+		weather, err := h.getTempByCity(ctx, city, lang, apiKey)
+		if err != nil {
+			slog.ErrorContext(ctx, "weather lookup for candidate city failed", "city", city, "error", err)
+			span.RecordError(err)
+			continue
+		}
+
+		tempF, tempK, tempR, tempRe := h.convertTemperatures(ctx, weather.TempC)
+		resp.Candidates = append(resp.Candidates, TempResponse{
+			City:      city,
+			TempC:     h.TemperatureFormat.temperaturePtr(weather.TempC),
+			TempF:     h.TemperatureFormat.temperaturePtr(tempF),
+			TempK:     h.TemperatureFormat.temperaturePtr(tempK),
+			TempR:     h.TemperatureFormat.temperaturePtr(tempR),
+			TempRe:    h.TemperatureFormat.temperaturePtr(tempRe),
+			Condition: weather.Condition,
+		})
+	}
+
+	span.SetAttributes(attribute.Int("candidates.count", len(resp.Candidates)))
+
+	if len(resp.Candidates) == 0 {
+		span.SetStatus(codes.Error, "failed to get temperature for candidates")
+		WriteError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "")
+	WriteJSON(w, resp, http.StatusOK)
+}
+
+func (h *Handler) searchCandidateCities(ctx context.Context, address *ViaCEPResponse) ([]string, error) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: search-candidate-cities")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("uf", address.UF),
+		attribute.String("city", address.City),
+		attribute.String("street", address.Street),
+	)
+
+	if address.UF == "" || len([]rune(address.Street)) < minViaCEPSearchLength {
+		span.SetStatus(codes.Ok, "")
+		return []string{address.City}, nil
+	}
+
+	ctx, cancel := h.withCallTimeout(ctx, providerViaCEP)
+	defer cancel()
+
+	requestURL, err := url.JoinPath(h.ViaCEPBaseURL, "ws",
+		url.PathEscape(address.UF), url.PathEscape(address.City), url.PathEscape(address.Street), "json/")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := h.doExternal(providerViaCEP, req)
+	if err != nil {
+		recordTimeout(span, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "http request failed")
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response body")
+		return nil, err
+	}
+
+	var results []ViaCEPResponse
+	if err := json.Unmarshal(body, &results); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "json unmarshal failed")
+		return nil, err
+	}
+
+	cities := []string{address.City}
+	seen := map[string]bool{address.City: true}
+	for _, result := range results {
+		if result.City == "" || seen[result.City] {
+			continue
+		}
+		seen[result.City] = true
+		cities = append(cities, result.City)
+	}
+
+	span.SetAttributes(attribute.Int("candidates.count", len(cities)))
+	span.SetStatus(codes.Ok, "")
+	return cities, nil
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const providerBrasilAPI = "brasilapi"
+
+type CEPProvider interface {
+	Name() string
+	Lookup(ctx context.Context, cep string) (*ViaCEPResponse, error)
+}
+
+type BrasilAPIResponse struct {
+	CEP          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+}
+
+type viaCEPProvider struct {
+	h *Handler
+}
+
+func (p *viaCEPProvider) Name() string {
+	return providerViaCEP
+}
+
+func (p *viaCEPProvider) Lookup(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: lookup-viacep")
+	defer span.End()
+
+	ctx, cancel := p.h.withCallTimeout(ctx, providerViaCEP)
+	defer cancel()
+
+	requestURL, err := url.JoinPath(p.h.ViaCEPBaseURL, "ws", cep, "json/")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.h.doExternal(providerViaCEP, req)
+	if err != nil {
+		recordTimeout(span, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "http request failed")
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response body")
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("viacep error: %d", resp.StatusCode)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "viacep returned error status")
+		return nil, err
+	}
+
+	address, err := p.h.decodeViaCEPResponse(ctx, body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to decode viacep response")
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return address, nil
+}
+
+type brasilAPIProvider struct {
+	h *Handler
+}
+
+func (p *brasilAPIProvider) Name() string {
+	return providerBrasilAPI
+}
+
+func (p *brasilAPIProvider) Lookup(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: lookup-brasilapi")
+	defer span.End()
+
+	ctx, cancel := p.h.withCallTimeout(ctx, providerBrasilAPI)
+	defer cancel()
+
+	requestURL, err := url.JoinPath(p.h.BrasilAPIBaseURL, "api", "cep", "v1", cep)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.h.doExternal(providerBrasilAPI, req)
+	if err != nil {
+		recordTimeout(span, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "http request failed")
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		span.RecordError(ErrNotFound)
+		span.SetStatus(codes.Error, "zipcode not found")
+		return nil, ErrNotFound
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response body")
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("brasilapi error: %d", resp.StatusCode)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "brasilapi returned error status")
+		return nil, err
+	}
+
+	var brasilAPI BrasilAPIResponse
+	if err := json.Unmarshal(body, &brasilAPI); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "json unmarshal failed")
+		return nil, err
+	}
+
+	city := strings.TrimSpace(brasilAPI.City)
+	if city == "" {
+		span.RecordError(ErrNotFound)
+		span.SetStatus(codes.Error, "zipcode not found")
+		return nil, ErrNotFound
+	}
+
+	span.SetAttributes(attribute.String("city", city))
+	span.SetStatus(codes.Ok, "")
+	return &ViaCEPResponse{City: city, UF: brasilAPI.State, Street: brasilAPI.Street}, nil
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type DebugInfo struct {
+	UpstreamRequests []string `json:"upstream_requests"`
+}
+
+type upstreamRecorder struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+type upstreamRecorderKey struct{}
+
+func withUpstreamRecorder(ctx context.Context) (context.Context, *upstreamRecorder) {
+	recorder := &upstreamRecorder{}
+	return context.WithValue(ctx, upstreamRecorderKey{}, recorder), recorder
+}
+
+func recordUpstreamRequest(req *http.Request) {
+	recorder, _ := req.Context().Value(upstreamRecorderKey{}).(*upstreamRecorder)
+	if recorder == nil {
+		return
+	}
+
+	called := *req.URL
+	if req.Header.Get(weatherAPIKeyHeader) != "" {
+		query := called.Query()
+		query.Set("key", "REDACTED")
+		called.RawQuery = query.Encode()
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.urls = append(recorder.urls, redactKey(called.String()))
+}
+
+func (r *upstreamRecorder) info() *DebugInfo {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &DebugInfo{UpstreamRequests: append([]string{}, r.urls...)}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWeatherHandlerDebugUpstreamRequests(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/ws/") {
+			w.Write([]byte(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`))
+			return
+		}
+		w.Write([]byte(`{"current":{"temp_c":20,"condition":{"text":"Sunny"}}}`))
+	}))
+	defer upstream.Close()
+
+	for _, debug := range []bool{false, true} {
+		client := &http.Client{Transport: NewAPIKeyTransport(http.DefaultTransport)}
+		h := NewHandler(secretAPIKey, client, "pt")
+		h.ViaCEPBaseURL = upstream.URL
+		h.WeatherAPIBaseURL = upstream.URL
+		h.Debug = debug
+
+		rec := serveWeather(h, "cep=01001000")
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("debug=%v: status = %d, want 200: %s", debug, rec.Code, rec.Body)
+		}
+		if strings.Contains(rec.Body.String(), secretAPIKey) {
+			t.Errorf("debug=%v: response leaks the API key: %s", debug, rec.Body)
+		}
+		var resp TempResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if !debug {
+			if resp.Debug != nil {
+				t.Errorf("debug info = %+v, want it omitted outside debug mode", resp.Debug)
+			}
+			continue
+		}
+
+		want := []string{
+			upstream.URL + "/ws/01001000/json/",
+			upstream.URL + "/v1/current.json?key=REDACTED&lang=pt&q=S%C3%A3o+Paulo",
+		}
+		if resp.Debug == nil || strings.Join(resp.Debug.UpstreamRequests, " ") != strings.Join(want, " ") {
+			t.Errorf("upstream_requests = %+v, want %v", resp.Debug, want)
+		}
+	}
+}
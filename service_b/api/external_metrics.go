@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	providerViaCEP     = "viacep"
+	providerWeatherAPI = "weatherapi"
+)
+
+type ExternalMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func NewExternalMetrics(registerer prometheus.Registerer) *ExternalMetrics {
+	m := &ExternalMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "external_request_duration_seconds",
+			Help:    "Latency of calls to external providers in seconds",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"provider"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "external_request_errors_total",
+			Help: "Failed calls to external providers, by transport error or non-200 status",
+		}, []string{"provider"}),
+	}
+	registerer.MustRegister(m.duration, m.errors)
+	return m
+}
+
+func (h *Handler) doExternal(provider string, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(httpx.WithRetryLimits(req.Context(), h.MaxRetries, h.RetryBaseDelay))
+	recordUpstreamRequest(req)
+	start := time.Now()
+	resp, err := h.HTTPClient.Do(req)
+	if h.External == nil {
+		return resp, err
+	}
+
+	h.External.duration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	if err != nil || resp.StatusCode != http.StatusOK {
+		h.External.errors.WithLabelValues(provider).Inc()
+	}
+	return resp, err
+}
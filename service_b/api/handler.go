@@ -1,43 +1,155 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	fahrenheitMultiplier = 1.8
 	fahrenheitBase       = 32
 	kelvinBase           = 273
+	rankineKelvinBase    = 273.15
+	rankineMultiplier    = 1.8
+	reaumurMultiplier    = 0.8
 )
 
-var ErrNotFound = errors.New("can not find zipcode")
+const (
+	DefaultMinPlausibleTempC = -90.0
+	DefaultMaxPlausibleTempC = 60.0
+)
+
+const (
+	DefaultViaCEPBaseURL     = "https://viacep.com.br"
+	DefaultBrasilAPIBaseURL  = "https://brasilapi.com.br"
+	DefaultWeatherAPIBaseURL = "https://api.weatherapi.com"
+)
+
+var DefaultUpstreamHosts = []string{"viacep.com.br", "brasilapi.com.br", "api.weatherapi.com"}
+
+var (
+	ErrNotFound               = errors.New("can not find zipcode")
+	ErrTemperatureUnavailable = errors.New("temperature not available")
+	ErrImplausibleTemperature = errors.New("implausible temperature from weather provider")
+	ErrRateLimited            = errors.New("weatherapi rate limited")
+	ErrUpstreamServer         = errors.New("weatherapi server error")
+)
 
 type Handler struct {
-	WeatherAPIKey string
-	HTTPClient    HTTPClient
+	WeatherAPIKey     string
+	HTTPClient        HTTPClient
+	DefaultLang       string
+	ViaCEPBaseURL     string
+	BrasilAPIBaseURL  string
+	WeatherAPIBaseURL string
+	CEPProviders      []CEPProvider
+	Stampede          *StampedeDetector
+	CityFallback      PrefixTable
+	RegionKeys        PrefixTable
+	Timezones         PrefixTable
+	CEPOverrides      map[string]string
+	ReservedPrefixes  []string
+	StartedAt         time.Time
+	Readiness         *httpx.Readiness
+	Sandbox           bool
+	ErrorLog          *httpx.ErrorLog
+	AdminToken        string
+	LogLevel          *slog.LevelVar
+	TraceURLTemplate  string
+	Debug             bool
+	Metrics           *httpx.HTTPMetrics
+	OTelMetrics       *httpx.OTelMetrics
+	External          *ExternalMetrics
+	CacheMetrics      *CacheMetrics
+	CEPCache          *cache.TTLCache[ViaCEPResponse]
+	WeatherCache      *cache.TTLCache[CurrentWeather]
+	History           *WeatherHistory
+
+	NearbyRadiusKm   float64
+	NearbyMaxResults int
+
+	StreamInterval time.Duration
+	MaxStreams     int
+
+	ViaCEPTimeout     time.Duration
+	WeatherAPITimeout time.Duration
+
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+
+	MinPlausibleTempC float64
+	MaxPlausibleTempC float64
+	StrictTempBounds  bool
+
+	TemperatureFormat TemperatureFormat
+
+	ServeStaleOnRateLimit bool
+	OmitUnavailableTemps  bool
+
+	weatherFlight singleflight.Group
+	activeStreams atomic.Int64
+	now           func() time.Time
 }
 
-func NewHandler(weatherAPIKey string, httpClient HTTPClient) *Handler {
-	return &Handler{
-		WeatherAPIKey: weatherAPIKey,
-		HTTPClient:    httpClient,
+func NewHandler(weatherAPIKey string, httpClient HTTPClient, defaultLang string) *Handler {
+	metrics := httpx.NewHTTPMetrics("service-b")
+	h := &Handler{
+		WeatherAPIKey:     weatherAPIKey,
+		HTTPClient:        httpClient,
+		DefaultLang:       defaultLang,
+		ViaCEPBaseURL:     DefaultViaCEPBaseURL,
+		BrasilAPIBaseURL:  DefaultBrasilAPIBaseURL,
+		WeatherAPIBaseURL: DefaultWeatherAPIBaseURL,
+		StartedAt:         time.Now(),
+		Metrics:           metrics,
+		OTelMetrics:       httpx.NewOTelMetrics("service-b"),
+		External:          NewExternalMetrics(metrics.Registry),
+		CacheMetrics:      NewCacheMetrics(metrics.Registry),
+
+		NearbyRadiusKm:   DefaultNearbyRadiusKm,
+		NearbyMaxResults: DefaultNearbyMaxResults,
+
+		StreamInterval: DefaultStreamInterval,
+		MaxStreams:     DefaultMaxStreams,
+
+		ViaCEPTimeout:     httpx.DefaultRequestTimeout,
+		WeatherAPITimeout: httpx.DefaultRequestTimeout,
+
+		MaxRetries:     httpx.DefaultRetryMaxRetries,
+		RetryBaseDelay: httpx.DefaultRetryBaseDelay,
+
+		MinPlausibleTempC: DefaultMinPlausibleTempC,
+		MaxPlausibleTempC: DefaultMaxPlausibleTempC,
+
+		TemperatureFormat: DefaultTemperatureFormat,
+
+		now: time.Now,
 	}
+	h.CEPProviders = []CEPProvider{&viaCEPProvider{h: h}, &brasilAPIProvider{h: h}}
+	return h
 }
 
 func (h *Handler) WeatherHandler(w http.ResponseWriter, r *http.Request) {
@@ -48,129 +160,376 @@ func (h *Handler) WeatherHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(ctx, "service-b: handle-weather")
 	defer span.End()
 
+	var upstream *upstreamRecorder
+	if h.Debug {
+		ctx, upstream = withUpstreamRecorder(ctx)
+	}
+
 	cep := r.URL.Query().Get("cep")
-	log.Printf("Request recebido: cep=%s, remote=%s", cep, r.RemoteAddr)
+	slog.InfoContext(ctx, "request received", "cep", cep, "remote", r.RemoteAddr)
+
+	if ceps := r.URL.Query()["cep"]; len(ceps) > 1 {
+		slog.WarnContext(ctx, "duplicate cep parameter", "cep_values", ceps)
+		span.SetAttributes(attribute.StringSlice("cep.values", ceps))
+		span.RecordError(fmt.Errorf("duplicate cep parameter"))
+		span.SetStatus(codes.Error, "duplicate cep parameter")
+		WriteError(w, "cep must be provided only once", http.StatusBadRequest)
+		return
+	}
 
-	if !IsValidCEP(cep) {
-		log.Printf("Erro: CEP invalido: %s", cep)
-		span.RecordError(fmt.Errorf("invalid zipcode: %s", cep))
+	cep, ok := NormalizeCEP(cep)
+	if !ok {
+		slog.WarnContext(ctx, "invalid zipcode", "cep", r.URL.Query().Get("cep"))
+		span.RecordError(fmt.Errorf("invalid zipcode: %s", r.URL.Query().Get("cep")))
 		span.SetStatus(codes.Error, "invalid zipcode")
 		WriteError(w, "invalid zipcode", http.StatusUnprocessableEntity)
 		return
 	}
 
+	if isReservedCEP(cep, h.ReservedPrefixes) {
+		slog.WarnContext(ctx, "zipcode in reserved range", "cep", cep)
+		span.SetAttributes(attribute.String("cep", cep))
+		span.RecordError(fmt.Errorf("reserved zipcode: %s", cep))
+		span.SetStatus(codes.Error, "reserved zipcode")
+		WriteErrorCode(w, "zipcode is in a reserved range", "RESERVED_ZIPCODE", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if h.Sandbox {
+		span.SetAttributes(attribute.String("cep", cep), attribute.Bool("sandbox", true))
+		span.SetStatus(codes.Ok, "")
+		WriteJSON(w, h.sandboxResponse(cep), http.StatusOK)
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		lang = h.DefaultLang
+	}
+	if lang != "" && !IsValidLang(lang) {
+		slog.WarnContext(ctx, "invalid lang", "lang", lang)
+		span.RecordError(fmt.Errorf("invalid lang: %s", lang))
+		span.SetStatus(codes.Error, "invalid lang")
+		WriteError(w, "invalid lang", http.StatusBadRequest)
+		return
+	}
+
 	span.SetAttributes(attribute.String("cep", cep))
 
-	city, err := h.getCityByCEP(ctx, cep)
+	degraded := false
+	var city string
+	address, err := h.getAddressByCEP(ctx, cep)
 	if err != nil {
 		span.RecordError(err)
 		if errors.Is(err, ErrNotFound) {
-			log.Printf("Erro: CEP nao encontrado: %s", cep)
+			slog.InfoContext(ctx, "zipcode not found", "cep", cep)
 			span.SetStatus(codes.Error, "zipcode not found")
 			WriteError(w, err.Error(), http.StatusNotFound)
-		} else {
-			log.Printf("Erro ao consultar ViaCEP: %v", err)
+			return
+		}
+
+		fallbackCity, ok := h.CityFallback.Lookup(cep)
+		if !ok && errors.Is(err, ErrBudgetExceeded) {
+			slog.WarnContext(ctx, "upstream budget exceeded on cep lookup", "error", err)
+			span.SetStatus(codes.Error, "upstream budget exceeded")
+			WriteErrorCode(w, "service temporarily unavailable", "UPSTREAM_BUDGET_EXCEEDED", http.StatusServiceUnavailable)
+			return
+		}
+		if !ok && isTimeout(err) {
+			slog.ErrorContext(ctx, "cep lookup timed out", "error", err)
+			recordTimeout(span, err)
+			span.SetStatus(codes.Error, "cep lookup timed out")
+			WriteErrorCode(w, "upstream timed out", "UPSTREAM_TIMEOUT", http.StatusGatewayTimeout)
+			return
+		}
+		if !ok {
+			slog.ErrorContext(ctx, "cep lookup failed", "error", err)
 			span.SetStatus(codes.Error, "failed to get city by cep")
 			WriteError(w, "internal error", http.StatusInternalServerError)
+			return
 		}
-		return
+
+		slog.WarnContext(ctx, "cep lookup unavailable, using fallback city", "cep", cep, "city", fallbackCity, "error", err)
+		span.SetAttributes(attribute.Bool("degraded", true))
+		city = fallbackCity
+		degraded = true
+	} else {
+		city = address.City
 	}
 
 	span.SetAttributes(attribute.String("city", city))
 
-	tempC, err := h.getTempByCity(ctx, city)
+	apiKey := h.weatherAPIKeyFor(cep)
+	if r.URL.Query().Get("candidates") == "true" && address != nil {
+		h.writeCandidates(ctx, w, address, lang, apiKey)
+		return
+	}
+
+	weather, err := h.getTempByCity(ctx, city, lang, apiKey)
 	if err != nil {
-		log.Printf("Erro ao consultar WeatherAPI para cidade %s: %v", city, err)
+		slog.ErrorContext(ctx, "weather lookup failed", "city", city, "error", err)
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to get temperature")
-		WriteError(w, "internal error", http.StatusInternalServerError)
+		if r.URL.Query().Get("allow_partial") == "true" {
+			span.SetAttributes(attribute.Bool("partial", true))
+			span.SetStatus(codes.Ok, "")
+			WriteJSON(w, PartialResponse{City: city, Partial: true, Message: "temperature not available"}, http.StatusOK)
+			return
+		}
+		if errors.Is(err, ErrTemperatureUnavailable) && h.OmitUnavailableTemps {
+			span.SetAttributes(attribute.Bool("temps_omitted", true))
+			span.SetStatus(codes.Ok, "")
+			WriteJSON(w, TempResponse{
+				CEP:      cep,
+				City:     city,
+				Degraded: degraded,
+				TraceURL: httpx.TraceURL(ctx, h.TraceURLTemplate),
+			}, http.StatusOK)
+			return
+		}
+		writeTemperatureError(w, span, err)
 		return
 	}
 
-	tempF, tempK := h.convertTemperatures(ctx, tempC)
+	tempF, tempK, tempR, tempRe := h.convertTemperatures(ctx, weather.TempC)
 
 	resp := TempResponse{
-		City:  city,
-		TempC: tempC,
-		TempF: tempF,
-		TempK: tempK,
+		CEP:       cep,
+		City:      city,
+		TempC:     h.TemperatureFormat.temperaturePtr(weather.TempC),
+		TempF:     h.TemperatureFormat.temperaturePtr(tempF),
+		TempK:     h.TemperatureFormat.temperaturePtr(tempK),
+		TempR:     h.TemperatureFormat.temperaturePtr(tempR),
+		TempRe:    h.TemperatureFormat.temperaturePtr(tempRe),
+		Condition: weather.Condition,
+		Degraded:  degraded,
+		Stale:     weather.Stale,
+		TraceURL:  httpx.TraceURL(ctx, h.TraceURLTemplate),
+		FetchedAt: weather.FetchedAt.Format(time.RFC3339),
+	}
+	if r.URL.Query().Get("formatted") == "true" {
+		resp.Formatted = h.TemperatureFormat.formatAll(weather.TempC, tempF, tempK, lang)
+	}
+	if r.URL.Query().Get("include_codes") == "true" && address != nil {
+		resp.IBGE = address.IBGE
+		resp.DDD = address.DDD
 	}
+	if r.URL.Query().Get("include_timezone") == "true" && address != nil {
+		resp.UTCOffset = h.Timezones[address.UF]
+	}
+	if r.URL.Query().Get("include_coords") == "true" {
+		resp.Lat = &weather.Lat
+		resp.Lon = &weather.Lon
+	}
+	resp.Debug = upstream.info()
 
-	log.Printf("Resposta: cep=%s, cidade=%s, tempC=%.2f", cep, city, tempC)
+	slog.InfoContext(ctx, "weather response", "cep", cep, "city", city, "temp_c", weather.TempC)
 	span.SetStatus(codes.Ok, "")
+	httpx.SetCacheHeader(ctx, w)
 	WriteJSON(w, resp, http.StatusOK)
 }
 
-func (h *Handler) convertTemperatures(ctx context.Context, tempC float64) (float64, float64) {
+func writeTemperatureError(w http.ResponseWriter, span trace.Span, err error) {
+	switch {
+	case errors.Is(err, ErrTemperatureUnavailable):
+		span.SetStatus(codes.Error, "temperature not available")
+		WriteErrorCode(w, err.Error(), "TEMPERATURE_UNAVAILABLE", http.StatusServiceUnavailable)
+	case errors.Is(err, ErrImplausibleTemperature):
+		span.SetStatus(codes.Error, "implausible temperature")
+		WriteErrorCode(w, err.Error(), "IMPLAUSIBLE_TEMPERATURE", http.StatusBadGateway)
+	case errors.Is(err, ErrBudgetExceeded):
+		span.SetStatus(codes.Error, "upstream budget exceeded")
+		WriteErrorCode(w, "service temporarily unavailable", "UPSTREAM_BUDGET_EXCEEDED", http.StatusServiceUnavailable)
+	case isTimeout(err):
+		recordTimeout(span, err)
+		span.SetStatus(codes.Error, "temperature lookup timed out")
+		WriteErrorCode(w, "upstream timed out", "UPSTREAM_TIMEOUT", http.StatusGatewayTimeout)
+	default:
+		span.SetStatus(codes.Error, "failed to get temperature")
+		WriteError(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) convertTemperatures(ctx context.Context, tempC float64) (float64, float64, float64, float64) {
 	tracer := otel.Tracer("service-b")
 	_, span := tracer.Start(ctx, "service-b: convert-temperatures")
 	defer span.End()
 
 	tempF := tempC*fahrenheitMultiplier + fahrenheitBase
 	tempK := tempC + kelvinBase
+	tempR := (tempC + rankineKelvinBase) * rankineMultiplier
+	tempRe := tempC * reaumurMultiplier
 
 	span.SetAttributes(
 		attribute.Float64("temp_C", tempC),
 		attribute.Float64("temp_F", tempF),
 		attribute.Float64("temp_K", tempK),
+		attribute.Float64("temp_R", tempR),
+		attribute.Float64("temp_Re", tempRe),
 	)
 	span.SetStatus(codes.Ok, "")
 
-	return tempF, tempK
+	return tempF, tempK, tempR, tempRe
+}
+
+func (h *Handler) weatherAPIKeyFor(cep string) string {
+	if key, ok := h.RegionKeys.Lookup(cep); ok {
+		return key
+	}
+	return h.WeatherAPIKey
+}
+
+func weatherCacheKey(city, lang, apiKey string) string {
+	fingerprint := fnv.New32a()
+	fingerprint.Write([]byte(apiKey))
+	return strings.ToLower(city) + "|" + lang + "|" + strconv.FormatUint(uint64(fingerprint.Sum32()), 16)
 }
 
-func (h *Handler) getTempByCity(ctx context.Context, city string) (float64, error) {
+func (h *Handler) getTempByCity(ctx context.Context, city, lang, apiKey string) (*CurrentWeather, error) {
 	tracer := otel.Tracer("service-b")
 	ctx, span := tracer.Start(ctx, "service-b: get-temp-by-city")
 	defer span.End()
 
-	span.SetAttributes(attribute.String("city", city))
+	span.SetAttributes(attribute.String("city", city), attribute.String("lang", lang))
+
+	key := weatherCacheKey(city, lang, apiKey)
+	if cached, hit := cacheGet(ctx, h.WeatherCache, "weather", key); hit {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		span.SetStatus(codes.Ok, "")
+		return &cached, nil
+	}
+	if h.WeatherCache != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+	}
+	h.Stampede.RecordMiss(ctx, "city:"+city)
 
-	requestURL := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s", h.WeatherAPIKey, url.QueryEscape(city))
+	flight := h.weatherFlight.DoChan(key, func() (any, error) {
+		fetchCtx, cancel := h.withFlightTimeout(ctx)
+		defer cancel()
+
+		weather, err := h.fetchTempByCity(fetchCtx, city, lang, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		cacheSet(fetchCtx, h.WeatherCache, "weather", key, *weather)
+		h.History.Record(city, WeatherSnapshot{FetchedAt: weather.FetchedAt, TempC: h.TemperatureFormat.temperature(weather.TempC)})
+		return weather, nil
+	})
+
+	var result any
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case res := <-flight:
+		result, err = res.Val, res.Err
+		span.SetAttributes(attribute.Bool("singleflight.shared", res.Shared))
+	}
+	if err != nil && h.ServeStaleOnRateLimit && errors.Is(err, ErrRateLimited) {
+		if stale, ok := h.WeatherCache.GetStale(key); ok {
+			slog.WarnContext(ctx, "weatherapi rate limited, serving stale weather", "city", city, "error", err)
+			span.AddEvent("serving stale weather", trace.WithAttributes(attribute.String("fetched_at", stale.FetchedAt.Format(time.RFC3339))))
+			span.SetAttributes(attribute.Bool("cache.stale", true))
+			span.SetStatus(codes.Ok, "")
+			stale.Stale = true
+			return &stale, nil
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to get temperature")
+		return nil, err
+	}
+
+	weather := *result.(*CurrentWeather)
+	span.SetStatus(codes.Ok, "")
+	return &weather, nil
+}
+
+func (h *Handler) fetchTempByCity(ctx context.Context, city, lang, apiKey string) (*CurrentWeather, error) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: fetch-temp-by-city")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("city", city), attribute.String("lang", lang))
+
+	ctx, cancel := h.withCallTimeout(ctx, providerWeatherAPI)
+	defer cancel()
+
+	endpoint, err := url.JoinPath(h.WeatherAPIBaseURL, "v1", "current.json")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	requestURL := endpoint + "?q=" + url.QueryEscape(city)
+	if lang != "" {
+		requestURL += "&lang=" + url.QueryEscape(lang)
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to create request")
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set(weatherAPIKeyHeader, apiKey)
 
-	resp, err := h.HTTPClient.Do(req)
+	resp, err := h.doExternal(providerWeatherAPI, req)
 	if err != nil {
+		err = redactError(err)
+		recordTimeout(span, err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "http request failed")
-		return 0, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	fetchedAt := h.now().UTC()
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to read response body")
-		return 0, fmt.Errorf("failed to read weatherapi response body: %w", err)
+		return nil, fmt.Errorf("failed to read weatherapi response body: %w", err)
 	}
 
 	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err := fmt.Errorf("%w: %s", ErrRateLimited, redactKey(string(body)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "weatherapi rate limited")
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		err := fmt.Errorf("%w: %d - %s", ErrUpstreamServer, resp.StatusCode, redactKey(string(body)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "weatherapi returned error status")
+		return nil, err
+	}
+
 	if resp.StatusCode != 200 {
-		err := fmt.Errorf("weatherapi error: %d - %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("weatherapi error: %d - %s", resp.StatusCode, redactKey(string(body)))
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "weatherapi returned error status")
-		return 0, err
+		return nil, err
 	}
 
-	tempC, err := h.decodeWeatherResponse(ctx, body)
+	weather, err := h.decodeWeatherResponse(ctx, body)
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "failed to decode weather response")
-		return 0, err
+		return nil, err
 	}
 
+	weather.FetchedAt = fetchedAt
+	h.Readiness.MarkReady()
 	span.SetStatus(codes.Ok, "")
-	return tempC, nil
+	return weather, nil
 }
 
-func (h *Handler) decodeWeatherResponse(ctx context.Context, body []byte) (float64, error) {
+func (h *Handler) decodeWeatherResponse(ctx context.Context, body []byte) (*CurrentWeather, error) {
 	tracer := otel.Tracer("service-b")
 	_, span := tracer.Start(ctx, "service-b: decode-weather-response")
 	defer span.End()
@@ -179,90 +538,155 @@ func (h *Handler) decodeWeatherResponse(ctx context.Context, body []byte) (float
 	if err := json.Unmarshal(body, &weather); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "json unmarshal failed")
-		return 0, err
+		return nil, err
+	}
+
+	if weather.Current.TempC == nil {
+		span.RecordError(ErrTemperatureUnavailable)
+		span.SetStatus(codes.Error, "temp_c is null")
+		return nil, ErrTemperatureUnavailable
+	}
+
+	tempC := *weather.Current.TempC
+	if tempC < h.MinPlausibleTempC || tempC > h.MaxPlausibleTempC {
+		slog.WarnContext(ctx, "suspicious temperature from weatherapi", "temp_c", tempC)
+		span.AddEvent("temperature.suspicious", trace.WithAttributes(
+			attribute.Float64("temp_c", tempC),
+			attribute.Float64("temp_c.min", h.MinPlausibleTempC),
+			attribute.Float64("temp_c.max", h.MaxPlausibleTempC),
+		))
+		if h.StrictTempBounds {
+			span.RecordError(ErrImplausibleTemperature)
+			span.SetStatus(codes.Error, "implausible temperature")
+			return nil, ErrImplausibleTemperature
+		}
 	}
 
-	span.SetAttributes(attribute.Float64("temp_c", weather.Current.TempC))
+	span.SetAttributes(attribute.Float64("temp_c", *weather.Current.TempC))
 	span.SetStatus(codes.Ok, "")
-	return weather.Current.TempC, nil
+	return &CurrentWeather{
+		TempC:     *weather.Current.TempC,
+		Condition: weather.Current.Condition.Text,
+		Lat:       weather.Location.Lat,
+		Lon:       weather.Location.Lon,
+	}, nil
 }
 
-func (h *Handler) getCityByCEP(ctx context.Context, cep string) (string, error) {
+func (h *Handler) getAddressByCEP(ctx context.Context, cep string) (*ViaCEPResponse, error) {
 	tracer := otel.Tracer("service-b")
 	ctx, span := tracer.Start(ctx, "service-b: get-city-by-cep")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("cep", cep))
 
-	requestURL := fmt.Sprintf("https://viacep.com.br/ws/%s/json/", cep)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to create request")
-		return "", fmt.Errorf("failed to create request: %w", err)
+	if city, ok := h.CEPOverrides[cep]; ok {
+		span.SetAttributes(attribute.Bool("override.hit", true), attribute.String("city", city))
+		span.SetStatus(codes.Ok, "")
+		return &ViaCEPResponse{City: city}, nil
 	}
 
-	resp, err := h.HTTPClient.Do(req)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "http request failed")
-		return "", err
+	cached, hit := cacheGet(ctx, h.CEPCache, "cep", cep)
+	if h.CEPCache != nil {
+		span.SetAttributes(attribute.Bool("cache.hit", hit))
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to read response body")
-		return "", err
+	if hit {
+		h.CacheMetrics.cepHits.Inc()
+		span.SetAttributes(attribute.String("city", cached.City))
+		span.SetStatus(codes.Ok, "")
+		return &cached, nil
 	}
 
-	city, err := h.decodeViaCEPResponse(ctx, body)
-	if err != nil {
+	h.Stampede.RecordMiss(ctx, "cep:"+cep)
+
+	var firstErr error
+	notFound := 0
+	for _, provider := range h.CEPProviders {
+		address, err := provider.Lookup(ctx, cep)
+		if err == nil {
+			cacheSet(ctx, h.CEPCache, "cep", cep, *address)
+			span.SetAttributes(attribute.String("cep.provider", provider.Name()), attribute.String("city", address.City))
+			span.SetStatus(codes.Ok, "")
+			return address, nil
+		}
+
+		slog.WarnContext(ctx, "cep provider lookup failed", "cep", cep, "provider", provider.Name(), "error", err)
 		span.RecordError(err)
-		span.SetStatus(codes.Error, "failed to decode viacep response")
-		return "", err
+		if errors.Is(err, ErrNotFound) {
+			notFound++
+		} else if firstErr == nil {
+			firstErr = err
+		}
 	}
 
-	span.SetAttributes(attribute.String("city", city))
-	span.SetStatus(codes.Ok, "")
-	return city, nil
+	if notFound == len(h.CEPProviders) {
+		span.SetStatus(codes.Error, "zipcode not found")
+		return nil, ErrNotFound
+	}
+	span.SetStatus(codes.Error, "all cep providers failed")
+	return nil, firstErr
 }
 
-func (h *Handler) decodeViaCEPResponse(ctx context.Context, body []byte) (string, error) {
+func (h *Handler) decodeViaCEPResponse(ctx context.Context, body []byte) (*ViaCEPResponse, error) {
 	tracer := otel.Tracer("service-b")
 	_, span := tracer.Start(ctx, "service-b: decode-viacep-response")
 	defer span.End()
 
 	var viaCEP ViaCEPResponse
-	if err := json.Unmarshal(body, &viaCEP); err != nil {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var results []ViaCEPResponse
+		if err := json.Unmarshal(trimmed, &results); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "json unmarshal failed")
+			return nil, err
+		}
+		span.SetAttributes(attribute.Int("viacep.results", len(results)))
+		if len(results) == 0 {
+			span.RecordError(ErrNotFound)
+			span.SetStatus(codes.Error, "zipcode not found")
+			return nil, ErrNotFound
+		}
+		viaCEP = results[0]
+	} else if err := json.Unmarshal(body, &viaCEP); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "json unmarshal failed")
-		return "", err
+		return nil, err
 	}
 
+	if viaCEP.City != "" && strings.TrimSpace(viaCEP.City) == "" {
+		span.SetAttributes(attribute.Bool("viacep.blank_city", true))
+	}
+	viaCEP.City = strings.TrimSpace(viaCEP.City)
+
 	if viaCEP.Error != "" || viaCEP.City == "" {
 		span.RecordError(ErrNotFound)
 		span.SetStatus(codes.Error, "zipcode not found")
-		return "", ErrNotFound
+		return nil, ErrNotFound
 	}
 
 	span.SetAttributes(attribute.String("city", viaCEP.City))
 	span.SetStatus(codes.Ok, "")
-	return viaCEP.City, nil
+	return &viaCEP, nil
 }
 
-func SetupRouter(h *Handler) http.Handler {
+func SetupRouter(h *Handler, cfg httpx.Config) http.Handler {
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Timeout(30 * time.Second))
-
-	r.Get("/weather", h.WeatherHandler)
+	r.Use(httpx.DefaultMiddleware(cfg)...)
+	r.Use(h.Metrics.Middleware, h.OTelMetrics.Middleware)
+
+	r.Group(func(r chi.Router) {
+		r.Use(httpx.Maintenance(cfg.MaintenanceMode))
+		r.Get("/weather", h.WeatherHandler)
+		r.Get("/weather/nearby", h.NearbyHandler)
+		r.Get("/weather/stream", h.StreamHandler)
+		r.Get("/weather/history", h.HistoryHandler)
+	})
+	r.With(httpx.RequireToken(h.AdminToken)).Get("/admin/status", h.StatusHandler)
+	r.With(httpx.RequireToken(h.AdminToken)).Post("/admin/loglevel", httpx.LogLevelHandler(h.LogLevel))
+	r.With(httpx.RequireToken(h.AdminToken)).Get("/admin/errors", h.ErrorLog.Handler)
+	r.Get("/healthz", httpx.Healthz)
+	r.Get("/readyz", h.Readiness.Handler)
+	r.Handle("/metrics", h.Metrics.Handler())
 
 	return otelhttp.NewHandler(r, "service-b-server")
 }
@@ -0,0 +1,765 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestGetTempByCitySharedFetchOutlivesFirstCaller(t *testing.T) {
+	var calls atomic.Int32
+	weatherAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`{"current":{"temp_c":21.5,"condition":{"text":"Sunny"}}}`))
+	}))
+	defer weatherAPI.Close()
+
+	h := NewHandler("key", http.DefaultClient, "pt")
+	h.WeatherAPIBaseURL = weatherAPI.URL
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := h.getTempByCity(firstCtx, "Sao Paulo", "pt", "key")
+		firstErr <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	secondDone := make(chan *CurrentWeather, 1)
+	go func() {
+		weather, err := h.getTempByCity(context.Background(), "Sao Paulo", "pt", "key")
+		if err != nil {
+			t.Errorf("second caller: %v", err)
+		}
+		secondDone <- weather
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancelFirst()
+
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller error = %v, want context.Canceled", err)
+	}
+	if weather := <-secondDone; weather == nil || weather.TempC != 21.5 {
+		t.Errorf("second caller weather = %+v, want temp 21.5", weather)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("weatherapi calls = %d, want 1", got)
+	}
+}
+
+func TestWeatherCacheKey(t *testing.T) {
+	base := weatherCacheKey("Sao Paulo", "pt", "key-a")
+
+	tests := []struct {
+		name     string
+		city     string
+		lang     string
+		apiKey   string
+		wantSame bool
+	}{
+		{"same inputs", "Sao Paulo", "pt", "key-a", true},
+		{"city case ignored", "SAO PAULO", "pt", "key-a", true},
+		{"different lang", "Sao Paulo", "en", "key-a", false},
+		{"different api key", "Sao Paulo", "pt", "key-b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := weatherCacheKey(tt.city, tt.lang, tt.apiKey)
+			if (key == base) != tt.wantSame {
+				t.Errorf("weatherCacheKey(%q, %q, %q) = %q, base %q, wantSame %v", tt.city, tt.lang, tt.apiKey, key, base, tt.wantSame)
+			}
+			if strings.Contains(key, tt.apiKey) {
+				t.Errorf("weatherCacheKey leaks the api key: %q", key)
+			}
+		})
+	}
+}
+
+func TestGetTempByCityRecordsEveryConcurrentMiss(t *testing.T) {
+	release := make(chan struct{})
+	weatherAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"current":{"temp_c":21.5,"condition":{"text":"Sunny"}}}`))
+	}))
+	defer weatherAPI.Close()
+
+	h := NewHandler("key", http.DefaultClient, "pt")
+	h.WeatherAPIBaseURL = weatherAPI.URL
+	h.Stampede = NewStampedeDetector(100, time.Minute)
+
+	const callers = 5
+	done := make(chan struct{}, callers)
+	for range callers {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			if _, err := h.getTempByCity(context.Background(), "Sao Paulo", "pt", "key"); err != nil {
+				t.Errorf("getTempByCity: %v", err)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for h.Stampede.missCount("city:Sao Paulo") < callers && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(release)
+	for range callers {
+		<-done
+	}
+
+	if got := h.Stampede.missCount("city:Sao Paulo"); got != callers {
+		t.Errorf("recorded misses = %d, want %d", got, callers)
+	}
+}
+
+func serveWeather(h *Handler, query string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.WeatherHandler(rec, httptest.NewRequest(http.MethodGet, "/weather?"+query, nil))
+	return rec
+}
+
+func TestWeatherHandlerRetriesOnlyInTransport(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int32
+		query      string
+		wantCalls  int32
+		wantStatus int
+		wantBody   string
+	}{
+		{"transport retry recovers", 1, "cep=01001000", 2, http.StatusOK, `"temp_C":21.5`},
+		{"exhausted retries allow partial", 100, "cep=01001000&allow_partial=true", 3, http.StatusOK, `"partial":true`},
+		{"exhausted retries", 100, "cep=01001000", 3, http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			weatherAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.Write([]byte(`{"current":{"temp_c":21.5,"condition":{"text":"Sunny"}}}`))
+			}))
+			defer weatherAPI.Close()
+			viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Errorf("ViaCEP called for a cached CEP: %s", r.URL)
+			}))
+			defer viaCEP.Close()
+
+			client := &http.Client{Transport: httpx.NewRetryTransport(http.DefaultTransport, httpx.RetryPolicy{})}
+			h := NewHandler("key", client, "pt")
+			h.ViaCEPBaseURL = viaCEP.URL
+			h.BrasilAPIBaseURL = viaCEP.URL
+			h.WeatherAPIBaseURL = weatherAPI.URL
+			h.MaxRetries = 2
+			h.RetryBaseDelay = time.Millisecond
+			h.CEPCache = cache.NewTTLCache[ViaCEPResponse](time.Minute)
+			h.CEPCache.Set("01001000", ViaCEPResponse{City: "Sao Paulo", UF: "SP"})
+
+			rec := serveWeather(h, tt.query)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body, tt.wantBody)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("WeatherAPI calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerReservedPrefixes(t *testing.T) {
+	tests := []struct {
+		name       string
+		prefixes   string
+		wantStatus int
+		wantCode   string
+	}{
+		{"not reserved by default", DefaultReservedCEPPrefixes, http.StatusOK, ""},
+		{"configured prefix", "000", http.StatusUnprocessableEntity, "RESERVED_ZIPCODE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes, err := ParseReservedPrefixes(tt.prefixes)
+			if err != nil {
+				t.Fatalf("ParseReservedPrefixes(%q): %v", tt.prefixes, err)
+			}
+			h := NewHandler("", http.DefaultClient, "pt")
+			h.Sandbox = true
+			h.ReservedPrefixes = prefixes
+
+			rec := serveWeather(h, "cep=00012345")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body ErrorResponse
+			json.NewDecoder(rec.Body).Decode(&body)
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+const (
+	stubViaCEPURL     = "http://viacep.test"
+	stubBrasilAPIURL  = "http://brasilapi.test"
+	stubWeatherAPIURL = "http://weatherapi.test"
+)
+
+// stubClient answers upstream calls in-process and records the URLs it saw.
+type stubClient struct {
+	respond func(req *http.Request) (int, string)
+
+	mu   sync.Mutex
+	urls []string
+}
+
+func (c *stubClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.urls = append(c.urls, req.URL.String())
+	c.mu.Unlock()
+
+	status, body := c.respond(req)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func (c *stubClient) calls(host string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var urls []string
+	for _, u := range c.urls {
+		if strings.HasPrefix(u, "http://"+host+"/") {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// newStubHandler returns a handler whose upstreams are served by respond.
+// BrasilAPI answers 404 unless respond handles brasilapi.test itself.
+func newStubHandler(respond func(req *http.Request) (int, string)) (*Handler, *stubClient) {
+	client := &stubClient{respond: respond}
+	h := NewHandler("key", client, "pt")
+	h.ViaCEPBaseURL = stubViaCEPURL
+	h.BrasilAPIBaseURL = stubBrasilAPIURL
+	h.WeatherAPIBaseURL = stubWeatherAPIURL
+	return h, client
+}
+
+func TestWeatherHandlerCandidates(t *testing.T) {
+	h, client := newStubHandler(func(req *http.Request) (int, string) {
+		switch {
+		case req.URL.Host == "viacep.test" && req.URL.Path == "/ws/01001000/json/":
+			return http.StatusOK, `{"localidade":"São Paulo","uf":"SP","logradouro":"Praça da Sé"}`
+		case req.URL.Host == "viacep.test" && strings.HasPrefix(req.URL.Path, "/ws/SP/"):
+			return http.StatusOK, `[{"localidade":"São Paulo"},{"localidade":"Osasco"},{"localidade":"Osasco"}]`
+		case req.URL.Host == "weatherapi.test":
+			return http.StatusOK, `{"current":{"temp_c":20,"condition":{"text":"Sunny"}}}`
+		}
+		return http.StatusNotFound, `{}`
+	})
+
+	rec := serveWeather(h, "cep=01001000&candidates=true")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp CandidatesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var cities []string
+	for _, candidate := range resp.Candidates {
+		cities = append(cities, candidate.City)
+	}
+	if strings.Join(cities, ",") != "São Paulo,Osasco" {
+		t.Errorf("candidate cities = %v, want São Paulo and Osasco once each", cities)
+	}
+	if got := len(client.calls("weatherapi.test")); got != 2 {
+		t.Errorf("WeatherAPI calls = %d, want one per candidate", got)
+	}
+}
+
+func TestWeatherHandlerBlankCityIsNotFound(t *testing.T) {
+	h, client := newStubHandler(func(req *http.Request) (int, string) {
+		if req.URL.Host == "viacep.test" {
+			return http.StatusOK, `{"cep":"01001-000","localidade":"   ","uf":"SP"}`
+		}
+		return http.StatusNotFound, `{}`
+	})
+
+	rec := serveWeather(h, "cep=01001000")
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404: %s", rec.Code, rec.Body)
+	}
+	if got := client.calls("weatherapi.test"); len(got) != 0 {
+		t.Errorf("WeatherAPI called for a blank city: %v", got)
+	}
+}
+
+func TestWeatherHandlerRejectsDuplicateCEP(t *testing.T) {
+	h, client := newStubHandler(func(*http.Request) (int, string) { return http.StatusOK, `{}` })
+
+	rec := serveWeather(h, "cep=01001000&cep=20040002")
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", rec.Code, rec.Body)
+	}
+	if len(client.urls) != 0 {
+		t.Errorf("upstream calls = %v, want none", client.urls)
+	}
+}
+
+// stubUpstreams answers ViaCEP with viaCEPBody and WeatherAPI with a fixed
+// current temperature.
+func stubUpstreams(viaCEPBody string) func(req *http.Request) (int, string) {
+	return func(req *http.Request) (int, string) {
+		switch req.URL.Host {
+		case "viacep.test":
+			return http.StatusOK, viaCEPBody
+		case "weatherapi.test":
+			return http.StatusOK, `{"current":{"temp_c":20,"condition":{"text":"Sunny"}}}`
+		}
+		return http.StatusNotFound, `{}`
+	}
+}
+
+func TestWeatherHandlerOverrideSkipsViaCEP(t *testing.T) {
+	h, client := newStubHandler(stubUpstreams(`{"localidade":"São Paulo","uf":"SP"}`))
+	overrides, err := LoadCEPOverrides("01001000:Campinas", "")
+	if err != nil {
+		t.Fatalf("LoadCEPOverrides: %v", err)
+	}
+	h.CEPOverrides = overrides
+
+	rec := serveWeather(h, "cep=01001000")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp TempResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.City != "Campinas" {
+		t.Errorf("city = %q, want the override", resp.City)
+	}
+	if got := client.calls("viacep.test"); len(got) != 0 {
+		t.Errorf("ViaCEP calls = %v, want none for an overridden CEP", got)
+	}
+}
+
+func TestWeatherHandlerArrayShapedViaCEPResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCity   string
+	}{
+		{"first result used", `[{"localidade":"Rio de Janeiro","uf":"RJ"},{"localidade":"Niterói","uf":"RJ"}]`, http.StatusOK, "Rio de Janeiro"},
+		{"empty array", `[]`, http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newStubHandler(stubUpstreams(tt.body))
+
+			rec := serveWeather(h, "cep=20040002")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var resp TempResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if resp.City != tt.wantCity {
+				t.Errorf("city = %q, want %q", resp.City, tt.wantCity)
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerTimezoneOffset(t *testing.T) {
+	timezones, err := LoadUFTimezones("")
+	if err != nil {
+		t.Fatalf("LoadUFTimezones: %v", err)
+	}
+	tests := []struct {
+		uf   string
+		want string
+	}{
+		{"SP", "-03:00"},
+		{"AM", "-04:00"},
+		{"AC", "-05:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uf, func(t *testing.T) {
+			h, _ := newStubHandler(stubUpstreams(`{"localidade":"Capital","uf":"` + tt.uf + `"}`))
+			h.Timezones = timezones
+
+			rec := serveWeather(h, "cep=01001000&include_timezone=true")
+
+			var resp TempResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.UTCOffset != tt.want {
+				t.Errorf("utc_offset = %q, want %q", resp.UTCOffset, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryHandlerReturnsSnapshotsAcrossFetches(t *testing.T) {
+	var temp atomic.Int32
+	h, _ := newStubHandler(func(req *http.Request) (int, string) {
+		if req.URL.Host == "viacep.test" {
+			return http.StatusOK, `{"localidade":"São Paulo","uf":"SP"}`
+		}
+		return http.StatusOK, fmt.Sprintf(`{"current":{"temp_c":%d}}`, 18+temp.Add(1))
+	})
+	h.History = NewWeatherHistory(2)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	for range 3 {
+		if rec := serveWeather(h, "cep=01001000"); rec.Code != http.StatusOK {
+			t.Fatalf("weather status = %d: %s", rec.Code, rec.Body)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+
+	rec := httptest.NewRecorder()
+	h.HistoryHandler(rec, httptest.NewRequest(http.MethodGet, "/weather/history?cep=01001000", nil))
+
+	var resp HistoryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []WeatherSnapshot{
+		{FetchedAt: time.Date(2026, 1, 1, 12, 10, 0, 0, time.UTC), TempC: 20},
+		{FetchedAt: time.Date(2026, 1, 1, 12, 20, 0, 0, time.UTC), TempC: 21},
+	}
+	if len(resp.Snapshots) != len(want) {
+		t.Fatalf("snapshots = %+v, want the last %d", resp.Snapshots, len(want))
+	}
+	for i, snapshot := range resp.Snapshots {
+		if !snapshot.FetchedAt.Equal(want[i].FetchedAt) || snapshot.TempC != want[i].TempC {
+			t.Errorf("snapshot %d = %+v, want %+v", i, snapshot, want[i])
+		}
+	}
+}
+
+func TestWeatherHandlerServesStaleOnRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		serveStale bool
+		wantStatus int
+	}{
+		{"stale served", true, http.StatusOK},
+		{"disabled", false, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var limited atomic.Bool
+			h, _ := newStubHandler(func(req *http.Request) (int, string) {
+				if req.URL.Host == "viacep.test" {
+					return http.StatusOK, `{"localidade":"São Paulo","uf":"SP"}`
+				}
+				if limited.Load() {
+					return http.StatusTooManyRequests, `{"error":{"message":"quota exceeded"}}`
+				}
+				return http.StatusOK, `{"current":{"temp_c":20}}`
+			})
+			h.ServeStaleOnRateLimit = tt.serveStale
+			h.WeatherCache = cache.NewTTLCache[CurrentWeather](time.Millisecond)
+			h.WeatherCache.StaleTTL = time.Hour
+
+			if rec := serveWeather(h, "cep=01001000"); rec.Code != http.StatusOK {
+				t.Fatalf("first request status = %d: %s", rec.Code, rec.Body)
+			}
+			time.Sleep(5 * time.Millisecond)
+			limited.Store(true)
+
+			rec := serveWeather(h, "cep=01001000")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !tt.serveStale {
+				return
+			}
+			var resp TempResponse
+			json.NewDecoder(rec.Body).Decode(&resp)
+			if !resp.Stale || resp.TempC == nil || *resp.TempC != 20 {
+				t.Errorf("response = %+v, want the stale 20°C reading", resp)
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerOmitsUnavailableTemperatures(t *testing.T) {
+	tests := []struct {
+		name       string
+		omit       bool
+		wantStatus int
+		wantCode   string
+	}{
+		{"omitted", true, http.StatusOK, ""},
+		{"reported", false, http.StatusServiceUnavailable, "TEMPERATURE_UNAVAILABLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newStubHandler(func(req *http.Request) (int, string) {
+				if req.URL.Host == "viacep.test" {
+					return http.StatusOK, `{"localidade":"São Paulo","uf":"SP"}`
+				}
+				return http.StatusOK, `{"current":{"temp_c":null}}`
+			})
+			h.OmitUnavailableTemps = tt.omit
+
+			rec := serveWeather(h, "cep=01001000")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if code, _ := body["code"].(string); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			if !tt.omit {
+				return
+			}
+			if body["city"] != "São Paulo" {
+				t.Errorf("city = %v, want São Paulo", body["city"])
+			}
+			for _, key := range []string{"temp_C", "temp_F", "temp_K", "temp_R", "temp_Re"} {
+				if value, ok := body[key]; ok {
+					t.Errorf("%s = %v, want it omitted", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerFallsBackToDefaultCity(t *testing.T) {
+	h, client := newStubHandler(func(req *http.Request) (int, string) {
+		if req.URL.Host == "weatherapi.test" {
+			return http.StatusOK, `{"current":{"temp_c":25}}`
+		}
+		return http.StatusServiceUnavailable, `{}`
+	})
+	fallback, err := ParsePrefixTable(DefaultCityFallbackTable)
+	if err != nil {
+		t.Fatalf("ParsePrefixTable: %v", err)
+	}
+	h.CityFallback = fallback
+
+	rec := serveWeather(h, "cep=20040002")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp TempResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.City != "Rio de Janeiro" || !resp.Degraded {
+		t.Errorf("response = %+v, want degraded Rio de Janeiro", resp)
+	}
+	if got := client.calls("weatherapi.test"); len(got) != 1 || !strings.Contains(got[0], "q=Rio+de+Janeiro") {
+		t.Errorf("WeatherAPI calls = %v, want one for the fallback city", got)
+	}
+}
+
+func TestWeatherHandlerUsesConfiguredBaseURLs(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/viacep/ws/01001000/json/":
+			w.Write([]byte(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`))
+		case "/weatherapi/v1/current.json":
+			w.Write([]byte(`{"current":{"temp_c":20,"condition":{"text":"Sunny"}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	h := NewHandler("key", upstream.Client(), "pt")
+	h.ViaCEPBaseURL = upstream.URL + "/viacep/"
+	h.WeatherAPIBaseURL = upstream.URL + "/weatherapi/"
+
+	rec := serveWeather(h, "cep=01001000")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	want := []string{"/viacep/ws/01001000/json/", "/weatherapi/v1/current.json"}
+	if fmt.Sprint(paths) != fmt.Sprint(want) {
+		t.Errorf("upstream paths = %v, want %v", paths, want)
+	}
+}
+
+func TestWeatherHandlerIncludeCodes(t *testing.T) {
+	const fullViaCEP = `{"cep":"01001-000","logradouro":"Praça da Sé","complemento":"lado ímpar","unidade":"","bairro":"Sé","localidade":"São Paulo","uf":"SP","estado":"São Paulo","regiao":"Sudeste","ibge":"3550308","gia":"1004","ddd":"11","siafi":"7107"}`
+	tests := []struct {
+		query    string
+		wantIBGE string
+		wantDDD  string
+	}{
+		{"cep=01001000&include_codes=true", "3550308", "11"},
+		{"cep=01001000", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			h, _ := newStubHandler(stubUpstreams(fullViaCEP))
+
+			rec := serveWeather(h, tt.query)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+			var body map[string]any
+			json.NewDecoder(rec.Body).Decode(&body)
+			for key, want := range map[string]string{"ibge": tt.wantIBGE, "ddd": tt.wantDDD} {
+				got, ok := body[key]
+				if want == "" && ok || want != "" && got != want {
+					t.Errorf("%s = %v (present %v), want %q", key, got, ok, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerTraceURL(t *testing.T) {
+	recorder := recordSpans(t)
+	h, _ := newStubHandler(stubUpstreams(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`))
+	h.TraceURLTemplate = "http://jaeger.test/trace/{trace_id}"
+
+	rec := serveWeather(h, "cep=01001000")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var resp TempResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	spans := recorder.Ended()
+	if len(spans) == 0 {
+		t.Fatal("no spans recorded")
+	}
+	want := "http://jaeger.test/trace/" + spans[0].SpanContext().TraceID().String()
+	if resp.TraceURL != want {
+		t.Errorf("trace_url = %q, want %q", resp.TraceURL, want)
+	}
+}
+
+func TestWeatherHandlerNullTemperature(t *testing.T) {
+	tests := []struct {
+		name       string
+		weather    string
+		wantStatus int
+		wantTempC  string
+	}{
+		{"null temp_c", `{"current":{"temp_c":null,"condition":{"text":"Sunny"}}}`, http.StatusServiceUnavailable, ""},
+		{"missing temp_c", `{"current":{"condition":{"text":"Sunny"}}}`, http.StatusServiceUnavailable, ""},
+		{"real zero", `{"current":{"temp_c":0,"condition":{"text":"Sunny"}}}`, http.StatusOK, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newStubHandler(func(req *http.Request) (int, string) {
+				if req.URL.Host == "weatherapi.test" {
+					return http.StatusOK, tt.weather
+				}
+				return http.StatusOK, `{"localidade":"São Paulo","uf":"SP"}`
+			})
+
+			rec := serveWeather(h, "cep=01001000")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var body map[string]json.RawMessage
+			json.NewDecoder(rec.Body).Decode(&body)
+			if got := string(body["temp_C"]); got != tt.wantTempC {
+				t.Errorf("temp_C = %q, want %q", got, tt.wantTempC)
+			}
+			if tt.wantStatus != http.StatusOK && string(body["code"]) != `"TEMPERATURE_UNAVAILABLE"` {
+				t.Errorf("code = %s, want TEMPERATURE_UNAVAILABLE", body["code"])
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerForwardsLang(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantLang   string
+	}{
+		{"default lang", "cep=01001000", http.StatusOK, "pt"},
+		{"requested lang", "cep=01001000&lang=es", http.StatusOK, "es"},
+		{"unsupported lang", "cep=01001000&lang=xx", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, client := newStubHandler(stubUpstreams(`{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`))
+
+			rec := serveWeather(h, tt.query)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			calls := client.calls("weatherapi.test")
+			if tt.wantLang == "" {
+				if len(calls) != 0 {
+					t.Errorf("WeatherAPI calls = %v, want none", calls)
+				}
+				return
+			}
+			if len(calls) != 1 {
+				t.Fatalf("WeatherAPI calls = %v, want one", calls)
+			}
+			called, _ := url.Parse(calls[0])
+			if got := called.Query().Get("lang"); got != tt.wantLang {
+				t.Errorf("outbound lang = %q, want %q", got, tt.wantLang)
+			}
+		})
+	}
+}
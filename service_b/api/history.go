@@ -0,0 +1,146 @@
+package api
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	DefaultWeatherHistorySize   = 10
+	DefaultWeatherHistoryCities = 1000
+)
+
+type WeatherSnapshot struct {
+	FetchedAt time.Time   `json:"fetched_at"`
+	TempC     Temperature `json:"temp_C"`
+}
+
+type HistoryResponse struct {
+	CEP       string            `json:"cep"`
+	City      string            `json:"city"`
+	Snapshots []WeatherSnapshot `json:"snapshots"`
+}
+
+type cityHistory struct {
+	key       string
+	snapshots []WeatherSnapshot
+}
+
+// WeatherHistory keeps the last size snapshots for at most MaxCities cities,
+// evicting the least recently updated city once the cap is reached.
+type WeatherHistory struct {
+	MaxCities int
+
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func NewWeatherHistory(size int) *WeatherHistory {
+	return &WeatherHistory{
+		MaxCities: DefaultWeatherHistoryCities,
+		size:      size,
+		entries:   map[string]*list.Element{},
+		order:     list.New(),
+	}
+}
+
+func (h *WeatherHistory) Record(city string, snapshot WeatherSnapshot) {
+	if h == nil || h.size <= 0 {
+		return
+	}
+
+	key := strings.ToLower(city)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	elem, ok := h.entries[key]
+	if !ok {
+		if h.MaxCities > 0 && h.order.Len() >= h.MaxCities {
+			oldest := h.order.Front()
+			h.order.Remove(oldest)
+			delete(h.entries, oldest.Value.(*cityHistory).key)
+		}
+		elem = h.order.PushBack(&cityHistory{key: key})
+		h.entries[key] = elem
+	}
+	h.order.MoveToBack(elem)
+
+	entry := elem.Value.(*cityHistory)
+	entry.snapshots = append(entry.snapshots, snapshot)
+	if len(entry.snapshots) > h.size {
+		entry.snapshots = entry.snapshots[len(entry.snapshots)-h.size:]
+	}
+}
+
+func (h *WeatherHistory) Snapshots(city string) []WeatherSnapshot {
+	if h == nil {
+		return []WeatherSnapshot{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	elem, ok := h.entries[strings.ToLower(city)]
+	if !ok {
+		return []WeatherSnapshot{}
+	}
+	return append([]WeatherSnapshot{}, elem.Value.(*cityHistory).snapshots...)
+}
+
+func (h *Handler) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(r.Context(), "service-b: handle-weather-history")
+	defer span.End()
+
+	cep, ok := NormalizeCEP(r.URL.Query().Get("cep"))
+	if !ok {
+		span.RecordError(fmt.Errorf("invalid zipcode: %s", r.URL.Query().Get("cep")))
+		span.SetStatus(codes.Error, "invalid zipcode")
+		WriteError(w, "invalid zipcode", http.StatusUnprocessableEntity)
+		return
+	}
+	span.SetAttributes(attribute.String("cep", cep))
+
+	if isReservedCEP(cep, h.ReservedPrefixes) {
+		span.RecordError(fmt.Errorf("reserved zipcode: %s", cep))
+		span.SetStatus(codes.Error, "reserved zipcode")
+		WriteErrorCode(w, "zipcode is in a reserved range", "RESERVED_ZIPCODE", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if h.Sandbox {
+		span.SetStatus(codes.Ok, "")
+		WriteJSON(w, HistoryResponse{CEP: cep, City: h.sandboxResponse(cep).City, Snapshots: []WeatherSnapshot{}}, http.StatusOK)
+		return
+	}
+
+	address, err := h.getAddressByCEP(ctx, cep)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, ErrNotFound) {
+			span.SetStatus(codes.Error, "zipcode not found")
+			WriteError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "cep lookup for history failed", "cep", cep, "error", err)
+		span.SetStatus(codes.Error, "failed to get city by cep")
+		WriteError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	snapshots := h.History.Snapshots(address.City)
+	span.SetAttributes(attribute.String("city", address.City), attribute.Int("history.count", len(snapshots)))
+	span.SetStatus(codes.Ok, "")
+	WriteJSON(w, HistoryResponse{CEP: cep, City: address.City, Snapshots: snapshots}, http.StatusOK)
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeatherHistoryEvictsLeastRecentlyUpdatedCity(t *testing.T) {
+	history := NewWeatherHistory(2)
+	history.MaxCities = 2
+	at := time.Unix(0, 0)
+	record := func(city string) {
+		at = at.Add(time.Minute)
+		history.Record(city, WeatherSnapshot{FetchedAt: at, TempC: 20})
+	}
+
+	record("São Paulo")
+	record("Rio de Janeiro")
+	record("são paulo")
+	record("Curitiba")
+
+	if got := history.Snapshots("Rio de Janeiro"); len(got) != 0 {
+		t.Errorf("Rio de Janeiro snapshots = %v, want it evicted as least recently updated", got)
+	}
+	if got := history.Snapshots("São Paulo"); len(got) != 2 {
+		t.Errorf("São Paulo snapshots = %d, want 2", len(got))
+	}
+	if got := history.Snapshots("Curitiba"); len(got) != 1 {
+		t.Errorf("Curitiba snapshots = %d, want 1", len(got))
+	}
+}
@@ -0,0 +1,15 @@
+package api
+
+var weatherAPILanguages = map[string]bool{
+	"ar": true, "bn": true, "bg": true, "zh": true, "zh_tw": true, "cs": true,
+	"da": true, "nl": true, "fi": true, "fr": true, "de": true, "el": true,
+	"hi": true, "hu": true, "it": true, "ja": true, "jv": true, "ko": true,
+	"zh_cmn": true, "mr": true, "pl": true, "pt": true, "pa": true, "ro": true,
+	"ru": true, "sr": true, "si": true, "sk": true, "es": true, "sv": true,
+	"ta": true, "te": true, "tr": true, "uk": true, "ur": true, "vi": true,
+	"zh_wuu": true, "zh_hsn": true, "zh_yue": true, "zu": true,
+}
+
+func IsValidLang(lang string) bool {
+	return weatherAPILanguages[lang]
+}
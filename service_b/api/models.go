@@ -1,29 +1,81 @@
 package api
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+var _ HTTPClient = (*http.Client)(nil)
+
 type TempResponse struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	CEP       string       `json:"cep,omitempty"`
+	City      string       `json:"city"`
+	TempC     *Temperature `json:"temp_C,omitempty"`
+	TempF     *Temperature `json:"temp_F,omitempty"`
+	TempK     *Temperature `json:"temp_K,omitempty"`
+	TempR     *Temperature `json:"temp_R,omitempty"`
+	TempRe    *Temperature `json:"temp_Re,omitempty"`
+	Condition string       `json:"condition,omitempty"`
+	Degraded  bool         `json:"degraded,omitempty"`
+	Stale     bool         `json:"stale,omitempty"`
+	IBGE      string       `json:"ibge,omitempty"`
+	DDD       string       `json:"ddd,omitempty"`
+	UTCOffset string       `json:"utc_offset,omitempty"`
+	TraceURL  string       `json:"trace_url,omitempty"`
+	FetchedAt string       `json:"fetched_at,omitempty"`
+	Lat       *float64     `json:"lat,omitempty"`
+	Lon       *float64     `json:"lon,omitempty"`
+
+	Formatted *FormattedTemperatures `json:"formatted,omitempty"`
+	Debug     *DebugInfo             `json:"debug,omitempty"`
+}
+
+type PartialResponse struct {
+	City    string `json:"city"`
+	Partial bool   `json:"partial"`
+	Message string `json:"message"`
 }
 
 type ErrorResponse struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
 }
 
 type ViaCEPResponse struct {
-	City  string `json:"localidade"`
-	Error string `json:"erro,omitempty"`
+	City   string `json:"localidade"`
+	UF     string `json:"uf"`
+	Street string `json:"logradouro"`
+	IBGE   string `json:"ibge"`
+	DDD    string `json:"ddd"`
+	Error  string `json:"erro,omitempty"`
+}
+
+type CandidatesResponse struct {
+	Candidates []TempResponse `json:"candidates"`
 }
 
 type WeatherAPIResponse struct {
+	Location struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"location"`
 	Current struct {
-		TempC float64 `json:"temp_c"`
+		TempC     *float64 `json:"temp_c"`
+		Condition struct {
+			Text string `json:"text"`
+		} `json:"condition"`
 	} `json:"current"`
 }
+
+type CurrentWeather struct {
+	TempC     float64
+	Condition string
+	Lat       float64
+	Lon       float64
+	FetchedAt time.Time
+	Stale     bool
+}
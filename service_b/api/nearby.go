@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	DefaultNearbyRadiusKm   = 50.0
+	DefaultNearbyMaxResults = 5
+	earthRadiusKm           = 6371.0
+)
+
+type WeatherAPISearchResult struct {
+	Name   string  `json:"name"`
+	Region string  `json:"region"`
+	Lat    float64 `json:"lat"`
+	Lon    float64 `json:"lon"`
+}
+
+type NearbyResponse struct {
+	City     string         `json:"city"`
+	RadiusKm float64        `json:"radius_km"`
+	Cities   []TempResponse `json:"cities"`
+}
+
+func (h *Handler) NearbyHandler(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(r.Context(), "service-b: handle-nearby")
+	defer span.End()
+
+	cep, ok := NormalizeCEP(r.URL.Query().Get("cep"))
+	if !ok {
+		span.RecordError(fmt.Errorf("invalid zipcode: %s", r.URL.Query().Get("cep")))
+		span.SetStatus(codes.Error, "invalid zipcode")
+		WriteError(w, "invalid zipcode", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if isReservedCEP(cep, h.ReservedPrefixes) {
+		span.SetAttributes(attribute.String("cep", cep))
+		span.RecordError(fmt.Errorf("reserved zipcode: %s", cep))
+		span.SetStatus(codes.Error, "reserved zipcode")
+		WriteErrorCode(w, "zipcode is in a reserved range", "RESERVED_ZIPCODE", http.StatusUnprocessableEntity)
+		return
+	}
+
+	radiusKm := h.NearbyRadiusKm
+	if v := r.URL.Query().Get("radius_km"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed <= 0 || parsed > h.NearbyRadiusKm {
+			span.SetStatus(codes.Error, "invalid radius")
+			WriteError(w, fmt.Sprintf("radius_km must be between 0 and %.0f", h.NearbyRadiusKm), http.StatusBadRequest)
+			return
+		}
+		radiusKm = parsed
+	}
+
+	span.SetAttributes(attribute.String("cep", cep), attribute.Float64("radius_km", radiusKm))
+
+	if h.Sandbox {
+		sandbox := h.sandboxResponse(cep)
+		span.SetAttributes(attribute.Bool("sandbox", true))
+		span.SetStatus(codes.Ok, "")
+		WriteJSON(w, NearbyResponse{City: sandbox.City, RadiusKm: radiusKm, Cities: []TempResponse{sandbox}}, http.StatusOK)
+		return
+	}
+
+	lang := h.DefaultLang
+	apiKey := h.weatherAPIKeyFor(cep)
+
+	address, err := h.getAddressByCEP(ctx, cep)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, ErrNotFound) {
+			span.SetStatus(codes.Error, "zipcode not found")
+			WriteError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		slog.ErrorContext(ctx, "cep lookup failed", "cep", cep, "error", err)
+		if errors.Is(err, ErrBudgetExceeded) {
+			span.SetStatus(codes.Error, "upstream budget exceeded")
+			WriteErrorCode(w, "service temporarily unavailable", "UPSTREAM_BUDGET_EXCEEDED", http.StatusServiceUnavailable)
+			return
+		}
+		if isTimeout(err) {
+			recordTimeout(span, err)
+			span.SetStatus(codes.Error, "cep lookup timed out")
+			WriteErrorCode(w, "upstream timed out", "UPSTREAM_TIMEOUT", http.StatusGatewayTimeout)
+			return
+		}
+		span.SetStatus(codes.Error, "failed to get city by cep")
+		WriteError(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	origin, err := h.getTempByCity(ctx, address.City, lang, apiKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "weather lookup failed", "city", address.City, "error", err)
+		span.RecordError(err)
+		writeTemperatureError(w, span, err)
+		return
+	}
+
+	places, err := h.searchNearbyPlaces(ctx, origin.Lat, origin.Lon, apiKey)
+	if err != nil {
+		slog.WarnContext(ctx, "nearby places search failed", "city", address.City, "error", err)
+		span.RecordError(err)
+	}
+
+	names := []string{address.City}
+	seen := map[string]bool{address.City: true}
+	for _, place := range places {
+		if len(names) >= h.NearbyMaxResults {
+			break
+		}
+		if seen[place.Name] || haversineKm(origin.Lat, origin.Lon, place.Lat, place.Lon) > radiusKm {
+			continue
+		}
+		seen[place.Name] = true
+		names = append(names, place.Name)
+	}
+
+	results := make([]*TempResponse, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			weather, err := h.getTempByCity(ctx, name, lang, apiKey)
+			if err != nil {
+				slog.ErrorContext(ctx, "weather lookup for nearby city failed", "city", name, "error", err)
+				return
+			}
+			tempF, tempK, tempR, tempRe := h.convertTemperatures(ctx, weather.TempC)
+			results[i] = &TempResponse{
+				City:      name,
+				TempC:     h.TemperatureFormat.temperaturePtr(weather.TempC),
+				TempF:     h.TemperatureFormat.temperaturePtr(tempF),
+				TempK:     h.TemperatureFormat.temperaturePtr(tempK),
+				TempR:     h.TemperatureFormat.temperaturePtr(tempR),
+				TempRe:    h.TemperatureFormat.temperaturePtr(tempRe),
+				Condition: weather.Condition,
+			}
+		}(i, name)
+	}
+	wg.Wait()
+
+	resp := NearbyResponse{City: address.City, RadiusKm: radiusKm, Cities: make([]TempResponse, 0, len(results))}
+	for _, result := range results {
+		if result != nil {
+			resp.Cities = append(resp.Cities, *result)
+		}
+	}
+
+	span.SetAttributes(attribute.Int("nearby.count", len(resp.Cities)))
+	span.SetStatus(codes.Ok, "")
+	WriteJSON(w, resp, http.StatusOK)
+}
+
+func (h *Handler) searchNearbyPlaces(ctx context.Context, lat, lon float64, apiKey string) ([]WeatherAPISearchResult, error) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: search-nearby-places")
+	defer span.End()
+
+	span.SetAttributes(attribute.Float64("lat", lat), attribute.Float64("lon", lon))
+
+	ctx, cancel := h.withCallTimeout(ctx, providerWeatherAPI)
+	defer cancel()
+
+	endpoint, err := url.JoinPath(h.WeatherAPIBaseURL, "v1", "search.json")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	requestURL := endpoint + "?q=" + url.QueryEscape(fmt.Sprintf("%f,%f", lat, lon))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set(weatherAPIKeyHeader, apiKey)
+
+	resp, err := h.doExternal(providerWeatherAPI, req)
+	if err != nil {
+		err = redactError(err)
+		recordTimeout(span, err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "http request failed")
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response body")
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("weatherapi search error: %d - %s", resp.StatusCode, redactKey(string(body)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "weatherapi returned error status")
+		return nil, err
+	}
+
+	var places []WeatherAPISearchResult
+	if err := json.Unmarshal(body, &places); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "json unmarshal failed")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("places.count", len(places)))
+	span.SetStatus(codes.Ok, "")
+	return places, nil
+}
+
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
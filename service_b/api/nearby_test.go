@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNearbyHandlerGuards(t *testing.T) {
+	tests := []struct {
+		name       string
+		cep        string
+		wantStatus int
+	}{
+		{"invalid zipcode", "123", http.StatusUnprocessableEntity},
+		{"reserved zipcode", "00000000", http.StatusUnprocessableEntity},
+		{"sandbox", "01001000", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler("", http.DefaultClient, "pt")
+			h.Sandbox = true
+			h.ReservedPrefixes = []string{"000"}
+
+			rec := httptest.NewRecorder()
+			h.NearbyHandler(rec, httptest.NewRequest(http.MethodGet, "/weather/nearby?cep="+tt.cep, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestWriteTemperatureError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"unavailable", ErrTemperatureUnavailable, http.StatusServiceUnavailable},
+		{"implausible", ErrImplausibleTemperature, http.StatusBadGateway},
+		{"budget exceeded", ErrBudgetExceeded, http.StatusServiceUnavailable},
+		{"timeout", fmt.Errorf("call: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"other", fmt.Errorf("weatherapi error: 400 - bad request"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeTemperatureError(rec, trace.SpanFromContext(context.Background()), tt.err)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
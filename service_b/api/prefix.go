@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const DefaultCityFallbackTable = "0:São Paulo,1:São Paulo,2:Rio de Janeiro,3:Belo Horizonte,4:Salvador,5:Recife,6:Fortaleza,7:Brasília,8:Curitiba,9:Porto Alegre"
+
+const DefaultReservedCEPPrefixes = ""
+
+type PrefixTable map[string]string
+
+func ParseReservedPrefixes(list string) ([]string, error) {
+	var prefixes []string
+	for _, prefix := range strings.Split(list, ",") {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if len(prefix) > 8 || strings.Trim(prefix, "0123456789") != "" {
+			return nil, fmt.Errorf("invalid reserved cep prefix %q", prefix)
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes, nil
+}
+
+func isReservedCEP(cep string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(cep, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func ParsePrefixTable(table string) (PrefixTable, error) {
+	entries := make(PrefixTable)
+	for _, entry := range strings.Split(table, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(entry, ":")
+		prefix, value = strings.TrimSpace(prefix), strings.TrimSpace(value)
+		if !ok || prefix == "" || value == "" {
+			return nil, fmt.Errorf("invalid prefix table entry %q", entry)
+		}
+		entries[prefix] = value
+	}
+	return entries, nil
+}
+
+func (t PrefixTable) Lookup(cep string) (string, bool) {
+	for i := len(cep); i > 0; i-- {
+		if value, ok := t[cep[:i]]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func LoadCEPOverrides(pairs, file string) (map[string]string, error) {
+	overrides := make(map[string]string)
+
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cep overrides file: %w", err)
+		}
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return nil, fmt.Errorf("failed to parse cep overrides file: %w", err)
+		}
+	}
+
+	table, err := ParsePrefixTable(pairs)
+	if err != nil {
+		return nil, err
+	}
+	for cep, city := range table {
+		overrides[cep] = city
+	}
+
+	for cep := range overrides {
+		if !IsValidCEP(cep) {
+			return nil, fmt.Errorf("invalid cep %q in overrides", cep)
+		}
+	}
+	return overrides, nil
+}
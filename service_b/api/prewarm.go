@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const DefaultPrewarmTimeout = 3 * time.Second
+
+func (h *Handler) Prewarm(ctx context.Context, timeout time.Duration) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(ctx, "service-b: prewarm-connections")
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, baseURL := range []string{h.ViaCEPBaseURL, h.BrasilAPIBaseURL, h.WeatherAPIBaseURL} {
+		wg.Add(1)
+		go func(baseURL string) {
+			defer wg.Done()
+			if err := h.prewarm(ctx, baseURL); err != nil {
+				slog.WarnContext(ctx, "connection prewarm failed", "upstream", baseURL, "error", err)
+				span.RecordError(err, trace.WithAttributes(attribute.String("upstream", baseURL)))
+				return
+			}
+			span.AddEvent("prewarm.ok", trace.WithAttributes(attribute.String("upstream", baseURL)))
+		}(baseURL)
+	}
+	wg.Wait()
+
+	span.SetStatus(codes.Ok, "")
+}
+
+func (h *Handler) prewarm(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return redactError(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
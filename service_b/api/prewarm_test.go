@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPrewarmHeadsEveryUpstream(t *testing.T) {
+	recorder := recordSpans(t)
+	var mu sync.Mutex
+	methods := map[string]string{}
+	h, _ := newStubHandler(func(req *http.Request) (int, string) {
+		mu.Lock()
+		methods[req.URL.Host] = req.Method
+		mu.Unlock()
+		if req.URL.Host == "brasilapi.test" {
+			return http.StatusServiceUnavailable, `{}`
+		}
+		return http.StatusOK, ``
+	})
+
+	h.Prewarm(context.Background(), time.Second)
+
+	for _, host := range []string{"viacep.test", "brasilapi.test", "weatherapi.test"} {
+		if got := methods[host]; got != http.MethodHead {
+			t.Errorf("prewarm request to %s = %q, want HEAD", host, got)
+		}
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "service-b: prewarm-connections" {
+		t.Fatalf("recorded %d spans, want the prewarm span", len(spans))
+	}
+	if got := len(spans[0].Events()); got != 3 {
+		t.Errorf("prewarm span has %d events, want one per upstream", got)
+	}
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+func (h *Handler) CheckViaCEP(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, h.ViaCEPBaseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		return redactError(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
@@ -2,9 +2,10 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"regexp"
+	"strings"
 )
 
 var cepRegex = regexp.MustCompile(`^\d{8}$`)
@@ -13,7 +14,7 @@ func WriteJSON(w http.ResponseWriter, data interface{}, code int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON: %v", err)
+		slog.Error("failed to encode json response", "error", err)
 	}
 }
 
@@ -21,6 +22,26 @@ func WriteError(w http.ResponseWriter, msg string, code int) {
 	WriteJSON(w, ErrorResponse{Message: msg}, code)
 }
 
+func WriteErrorCode(w http.ResponseWriter, msg, errCode string, code int) {
+	WriteJSON(w, ErrorResponse{Message: msg, Code: errCode}, code)
+}
+
 func IsValidCEP(cep string) bool {
 	return cepRegex.MatchString(cep)
 }
+
+func NormalizeCEP(cep string) (string, bool) {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(cep) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == ' ' || r == '.':
+		default:
+			return "", false
+		}
+	}
+
+	normalized := b.String()
+	return normalized, IsValidCEP(normalized)
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+func sandboxTemperature(cep string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(cep))
+	return math.Round((float64(h.Sum32()%400)/10-5)*10) / 10
+}
+
+func (h *Handler) sandboxResponse(cep string) TempResponse {
+	tempC := sandboxTemperature(cep)
+	return TempResponse{
+		City:      "Sandbox " + cep[:5],
+		TempC:     h.TemperatureFormat.temperaturePtr(tempC),
+		TempF:     h.TemperatureFormat.temperaturePtr(tempC*fahrenheitMultiplier + fahrenheitBase),
+		TempK:     h.TemperatureFormat.temperaturePtr(tempC + kelvinBase),
+		TempR:     h.TemperatureFormat.temperaturePtr((tempC + rankineKelvinBase) * rankineMultiplier),
+		TempRe:    h.TemperatureFormat.temperaturePtr(tempC * reaumurMultiplier),
+		Condition: "sandbox",
+	}
+}
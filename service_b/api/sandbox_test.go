@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestWeatherHandlerSandboxIsDeterministic(t *testing.T) {
+	h, client := newStubHandler(func(req *http.Request) (int, string) {
+		return http.StatusInternalServerError, `{}`
+	})
+	h.Sandbox = true
+
+	first := serveWeather(h, "cep=01001000")
+	second := serveWeather(h, "cep=01001000")
+	other := serveWeather(h, "cep=20040002")
+
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", first.Code, first.Body)
+	}
+	if first.Body.String() != second.Body.String() {
+		t.Errorf("same CEP gave different bodies:\n%s\n%s", first.Body, second.Body)
+	}
+	if first.Body.String() == other.Body.String() {
+		t.Errorf("different CEPs gave the same body: %s", first.Body)
+	}
+
+	var resp TempResponse
+	if err := json.Unmarshal(first.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.City != "Sandbox 01001" || resp.TempC == nil || float64(*resp.TempC) != sandboxTemperature("01001000") {
+		t.Errorf("response = %+v, want the canned sandbox weather for 01001000", resp)
+	}
+	if len(client.urls) != 0 {
+		t.Errorf("sandbox mode called upstreams: %v", client.urls)
+	}
+}
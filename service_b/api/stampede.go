@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const DefaultStampedeCleanupInterval = time.Minute
+
+type StampedeDetector struct {
+	Threshold int
+	Window    time.Duration
+
+	mu     sync.Mutex
+	misses map[string][]time.Time
+	now    func() time.Time
+}
+
+func NewStampedeDetector(threshold int, window time.Duration) *StampedeDetector {
+	return &StampedeDetector{
+		Threshold: threshold,
+		Window:    window,
+		misses:    make(map[string][]time.Time),
+		now:       time.Now,
+	}
+}
+
+func (d *StampedeDetector) RecordMiss(ctx context.Context, key string) bool {
+	if d == nil || d.Threshold <= 0 {
+		return false
+	}
+
+	d.mu.Lock()
+	now := d.now()
+	cutoff := now.Add(-d.Window)
+	recent := d.misses[key][:0]
+	for _, t := range d.misses[key] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+	d.misses[key] = recent
+	count := len(recent)
+	d.mu.Unlock()
+
+	if count <= d.Threshold {
+		return false
+	}
+
+	slog.WarnContext(ctx, "cache stampede detected", "key", key, "misses", count, "window", d.Window.String())
+	trace.SpanFromContext(ctx).AddEvent("cache.stampede", trace.WithAttributes(
+		attribute.String("cache.key", key),
+		attribute.Int("cache.misses", count),
+	))
+	return true
+}
+
+func (d *StampedeDetector) Cleanup() int {
+	if d == nil {
+		return 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	removed := 0
+	cutoff := d.now().Add(-d.Window)
+	for key, times := range d.misses {
+		if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+			delete(d.misses, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (d *StampedeDetector) StartCleanup(ctx context.Context, interval time.Duration) {
+	if d == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.Cleanup()
+			}
+		}
+	}()
+}
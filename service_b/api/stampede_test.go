@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func (d *StampedeDetector) missCount(key string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.misses[key])
+}
+
+func TestStampedeDetectorCleanup(t *testing.T) {
+	now := time.Unix(0, 0)
+	d := NewStampedeDetector(10, time.Second)
+	d.now = func() time.Time { return now }
+
+	d.RecordMiss(context.Background(), "cep:01001000")
+	now = now.Add(500 * time.Millisecond)
+	d.RecordMiss(context.Background(), "cep:20040002")
+
+	if removed := d.Cleanup(); removed != 0 {
+		t.Fatalf("Cleanup() inside the window removed %d, want 0", removed)
+	}
+
+	now = now.Add(700 * time.Millisecond)
+	if removed := d.Cleanup(); removed != 1 {
+		t.Fatalf("Cleanup() removed %d, want 1", removed)
+	}
+	if got := d.missCount("cep:01001000"); got != 0 {
+		t.Errorf("expired key still holds %d misses", got)
+	}
+	if got := d.missCount("cep:20040002"); got != 1 {
+		t.Errorf("live key holds %d misses, want 1", got)
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func (h *Handler) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := httpx.NewServiceStatus("service-b", h.StartedAt)
+	status.Details = map[string]any{
+		"default_lang":          h.DefaultLang,
+		"stampede_detection":    h.Stampede != nil,
+		"city_fallback_enabled": h.CityFallback != nil,
+		"cep_cache":             h.CEPCache.Stats(),
+		"weather_cache":         h.WeatherCache.Stats(),
+	}
+	httpx.WriteStatus(w, status, status.Status)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestStatusEndpoint(t *testing.T) {
+	h := NewHandler("key", http.DefaultClient, "pt")
+	h.AdminToken = "secret"
+	h.CEPCache = cache.NewTTLCache[ViaCEPResponse](time.Minute)
+	h.CEPCache.Set("01001000", ViaCEPResponse{City: "Sao Paulo"})
+	h.CEPCache.Get("01001000")
+	h.CEPCache.Get("20040002")
+	router := SetupRouter(h, httpx.Config{Timeout: 5 * time.Second})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"missing token", "", http.StatusUnauthorized},
+		{"wrong token", "nope", http.StatusUnauthorized},
+		{"valid token", "secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var status struct {
+				Details struct {
+					CEPCache     cache.Stats `json:"cep_cache"`
+					WeatherCache cache.Stats `json:"weather_cache"`
+				} `json:"details"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if want := (cache.Stats{Entries: 1, Hits: 1, Misses: 1}); status.Details.CEPCache != want {
+				t.Errorf("cep_cache = %+v, want %+v", status.Details.CEPCache, want)
+			}
+			if status.Details.WeatherCache != (cache.Stats{}) {
+				t.Errorf("weather_cache = %+v, want zero for a disabled cache", status.Details.WeatherCache)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	DefaultStreamInterval     = 60 * time.Second
+	DefaultMaxStreams         = 100
+	DefaultStreamWriteTimeout = 10 * time.Second
+)
+
+func (h *Handler) acquireStream() bool {
+	if h.MaxStreams <= 0 {
+		h.activeStreams.Add(1)
+		return true
+	}
+	for {
+		active := h.activeStreams.Load()
+		if active >= int64(h.MaxStreams) {
+			return false
+		}
+		if h.activeStreams.CompareAndSwap(active, active+1) {
+			return true
+		}
+	}
+}
+
+func (h *Handler) releaseStream() {
+	h.activeStreams.Add(-1)
+}
+
+func (h *Handler) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	tracer := otel.Tracer("service-b")
+	ctx, span := tracer.Start(r.Context(), "service-b: handle-weather-stream")
+	defer span.End()
+
+	cep, ok := NormalizeCEP(r.URL.Query().Get("cep"))
+	if !ok {
+		span.RecordError(fmt.Errorf("invalid zipcode: %s", r.URL.Query().Get("cep")))
+		span.SetStatus(codes.Error, "invalid zipcode")
+		WriteError(w, "invalid zipcode", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if isReservedCEP(cep, h.ReservedPrefixes) {
+		span.SetAttributes(attribute.String("cep", cep))
+		span.RecordError(fmt.Errorf("reserved zipcode: %s", cep))
+		span.SetStatus(codes.Error, "reserved zipcode")
+		WriteErrorCode(w, "zipcode is in a reserved range", "RESERVED_ZIPCODE", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !h.acquireStream() {
+		slog.WarnContext(ctx, "stream limit reached, rejecting", "max_streams", h.MaxStreams, "cep", cep)
+		span.SetAttributes(attribute.Int("stream.max", h.MaxStreams))
+		span.SetStatus(codes.Error, "too many streams")
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(h.streamInterval().Seconds()))))
+		WriteErrorCode(w, "too many concurrent streams", "TOO_MANY_STREAMS", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.releaseStream()
+	span.SetAttributes(attribute.Int64("stream.active", h.activeStreams.Load()))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		span.SetStatus(codes.Error, "streaming not supported")
+		WriteError(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	interval := h.streamInterval()
+	span.SetAttributes(attribute.String("cep", cep), attribute.String("stream.interval", interval.String()))
+
+	var city string
+	if !h.Sandbox {
+		address, err := h.getAddressByCEP(ctx, cep)
+		if err != nil {
+			span.RecordError(err)
+			if errors.Is(err, ErrNotFound) {
+				span.SetStatus(codes.Error, "zipcode not found")
+				WriteError(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			slog.ErrorContext(ctx, "cep lookup for stream failed", "cep", cep, "error", err)
+			span.SetStatus(codes.Error, "failed to get city by cep")
+			WriteError(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		city = address.City
+		span.SetAttributes(attribute.String("city", city))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	controller := http.NewResponseController(w)
+	extendWriteDeadline(ctx, controller)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	slog.InfoContext(ctx, "stream started", "cep", cep, "interval", interval.String(), "remote", r.RemoteAddr)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	events := 0
+	for {
+		extendWriteDeadline(ctx, controller)
+		if err := h.pushWeatherEvent(ctx, w, cep, city); err != nil {
+			slog.WarnContext(ctx, "failed to write stream event", "cep", cep, "events", events, "error", err)
+			span.RecordError(err)
+			span.SetAttributes(attribute.Int("stream.events", events))
+			span.SetStatus(codes.Error, "failed to write event")
+			return
+		}
+		flusher.Flush()
+		events++
+
+		select {
+		case <-ctx.Done():
+			slog.InfoContext(ctx, "stream closed by client", "cep", cep, "events", events)
+			span.SetAttributes(attribute.Int("stream.events", events))
+			span.SetStatus(codes.Ok, "")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func extendWriteDeadline(ctx context.Context, controller *http.ResponseController) {
+	if err := controller.SetWriteDeadline(time.Now().Add(DefaultStreamWriteTimeout)); err != nil {
+		slog.DebugContext(ctx, "cannot extend stream write deadline", "error", err)
+	}
+}
+
+func (h *Handler) streamInterval() time.Duration {
+	if h.StreamInterval <= 0 {
+		return DefaultStreamInterval
+	}
+	return h.StreamInterval
+}
+
+func (h *Handler) pushWeatherEvent(ctx context.Context, w http.ResponseWriter, cep, city string) error {
+	if h.Sandbox {
+		return writeEvent(w, "weather", h.sandboxResponse(cep))
+	}
+
+	weather, err := h.getTempByCity(ctx, city, h.DefaultLang, h.weatherAPIKeyFor(cep))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		slog.ErrorContext(ctx, "weather lookup for stream failed", "city", city, "error", err)
+		return writeEvent(w, "error", ErrorResponse{Message: "temperature not available"})
+	}
+
+	tempF, tempK, tempR, tempRe := h.convertTemperatures(ctx, weather.TempC)
+	return writeEvent(w, "weather", TempResponse{
+		CEP:       cep,
+		City:      city,
+		TempC:     h.TemperatureFormat.temperaturePtr(weather.TempC),
+		TempF:     h.TemperatureFormat.temperaturePtr(tempF),
+		TempK:     h.TemperatureFormat.temperaturePtr(tempK),
+		TempR:     h.TemperatureFormat.temperaturePtr(tempR),
+		TempRe:    h.TemperatureFormat.temperaturePtr(tempRe),
+		Condition: weather.Condition,
+		TraceURL:  httpx.TraceURL(ctx, h.TraceURLTemplate),
+		FetchedAt: weather.FetchedAt.Format(time.RFC3339),
+	})
+}
+
+func writeEvent(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
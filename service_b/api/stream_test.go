@@ -0,0 +1,44 @@
+package api
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+func TestStreamHandlerOutlivesServerWriteTimeout(t *testing.T) {
+	h := NewHandler("", http.DefaultClient, "pt")
+	h.Sandbox = true
+	h.StreamInterval = 150 * time.Millisecond
+
+	srv := httptest.NewUnstartedServer(SetupRouter(h, httpx.Config{Timeout: 5 * time.Second}))
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/weather/stream?cep=01001000")
+	if err != nil {
+		t.Fatalf("GET stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	const wantEvents = 3
+	events := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for events < wantEvents && scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event: weather") {
+			events++
+		}
+	}
+	if events < wantEvents {
+		t.Errorf("received %d events before the stream broke (%v), want %d", events, scanner.Err(), wantEvents)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultTemperaturePrecision = 2
+	MaxTemperaturePrecision     = 6
+)
+
+const (
+	RoundingHalfUp   = "half-up"
+	RoundingHalfEven = "half-even"
+	RoundingTruncate = "truncate"
+)
+
+func IsValidRoundingMode(mode string) bool {
+	return mode == RoundingHalfUp || mode == RoundingHalfEven || mode == RoundingTruncate
+}
+
+type TemperatureFormat struct {
+	Precision int
+	Rounding  string
+}
+
+var DefaultTemperatureFormat = TemperatureFormat{Precision: DefaultTemperaturePrecision, Rounding: RoundingHalfUp}
+
+func NewTemperatureFormat(precision int, rounding string) (TemperatureFormat, error) {
+	if precision < 0 || precision > MaxTemperaturePrecision {
+		return TemperatureFormat{}, fmt.Errorf("temperature precision %d must be between 0 and %d", precision, MaxTemperaturePrecision)
+	}
+	if rounding == "" {
+		rounding = RoundingHalfUp
+	}
+	if !IsValidRoundingMode(rounding) {
+		return TemperatureFormat{}, fmt.Errorf("rounding mode %q must be one of %s, %s or %s", rounding, RoundingHalfUp, RoundingHalfEven, RoundingTruncate)
+	}
+	return TemperatureFormat{Precision: precision, Rounding: rounding}, nil
+}
+
+func (f TemperatureFormat) round(v float64) float64 {
+	factor := math.Pow(10, float64(f.Precision))
+	switch f.Rounding {
+	case RoundingHalfEven:
+		return math.RoundToEven(v*factor) / factor
+	case RoundingTruncate:
+		return math.Trunc(v*factor) / factor
+	default:
+		return math.Floor(v*factor+0.5) / factor
+	}
+}
+
+func (f TemperatureFormat) temperature(v float64) Temperature {
+	return Temperature(f.round(v))
+}
+
+func (f TemperatureFormat) temperaturePtr(v float64) *Temperature {
+	t := f.temperature(v)
+	return &t
+}
+
+type Temperature float64
+
+func (t Temperature) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(t), 'f', -1, 64)), nil
+}
+
+var commaDecimalLangs = map[string]bool{
+	"pt": true, "es": true, "fr": true, "de": true, "it": true, "nl": true,
+	"ru": true, "pl": true, "cs": true, "sk": true, "ro": true, "tr": true,
+	"uk": true, "fi": true, "sv": true, "da": true, "el": true, "hu": true,
+	"bg": true, "sr": true,
+}
+
+type FormattedTemperatures struct {
+	Celsius    string `json:"celsius"`
+	Fahrenheit string `json:"fahrenheit"`
+	Kelvin     string `json:"kelvin"`
+}
+
+func (f TemperatureFormat) format(v float64, unit, lang string) string {
+	formatted := strconv.FormatFloat(f.round(v), 'f', -1, 64)
+	if commaDecimalLangs[lang] {
+		formatted = strings.Replace(formatted, ".", ",", 1)
+	}
+	return formatted + " " + unit
+}
+
+func (f TemperatureFormat) formatAll(tempC, tempF, tempK float64, lang string) *FormattedTemperatures {
+	return &FormattedTemperatures{
+		Celsius:    f.format(tempC, "°C", lang),
+		Fahrenheit: f.format(tempF, "°F", lang),
+		Kelvin:     f.format(tempK, "K", lang),
+	}
+}
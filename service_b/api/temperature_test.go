@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestNewTemperatureFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		precision int
+		rounding  string
+		want      TemperatureFormat
+		wantErr   bool
+	}{
+		{"defaults rounding to half-up", 2, "", TemperatureFormat{Precision: 2, Rounding: RoundingHalfUp}, false},
+		{"zero precision", 0, RoundingTruncate, TemperatureFormat{Precision: 0, Rounding: RoundingTruncate}, false},
+		{"max precision", MaxTemperaturePrecision, RoundingHalfEven, TemperatureFormat{Precision: MaxTemperaturePrecision, Rounding: RoundingHalfEven}, false},
+		{"negative precision", -1, RoundingHalfUp, TemperatureFormat{}, true},
+		{"precision too large", MaxTemperaturePrecision + 1, RoundingHalfUp, TemperatureFormat{}, true},
+		{"unknown rounding", 2, "ceil", TemperatureFormat{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewTemperatureFormat(tt.precision, tt.rounding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTemperatureFormat(%d, %q) error = %v, wantErr %v", tt.precision, tt.rounding, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NewTemperatureFormat(%d, %q) = %+v, want %+v", tt.precision, tt.rounding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemperatureFormatRound(t *testing.T) {
+	tests := []struct {
+		name   string
+		format TemperatureFormat
+		value  float64
+		want   float64
+	}{
+		{"half-up", TemperatureFormat{Precision: 1, Rounding: RoundingHalfUp}, 21.26, 21.3},
+		{"half-up positive boundary", TemperatureFormat{Precision: 0, Rounding: RoundingHalfUp}, 2.5, 3},
+		{"half-up negative boundary", TemperatureFormat{Precision: 0, Rounding: RoundingHalfUp}, -2.5, -2},
+		{"half-up positive boundary with precision", TemperatureFormat{Precision: 1, Rounding: RoundingHalfUp}, 21.25, 21.3},
+		{"half-up negative boundary with precision", TemperatureFormat{Precision: 1, Rounding: RoundingHalfUp}, -21.25, -21.2},
+		{"half-even", TemperatureFormat{Precision: 0, Rounding: RoundingHalfEven}, 22.5, 22},
+		{"truncate", TemperatureFormat{Precision: 1, Rounding: RoundingTruncate}, 21.29, 21.2},
+		{"zero precision", TemperatureFormat{Precision: 0, Rounding: RoundingHalfUp}, 21.6, 22},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.round(tt.value); got != tt.want {
+				t.Errorf("round(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemperatureFormatFormat(t *testing.T) {
+	format := TemperatureFormat{Precision: 1, Rounding: RoundingHalfUp}
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"en", "21.3 °C"},
+		{"pt", "21,3 °C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			if got := format.format(21.26, "°C", tt.lang); got != tt.want {
+				t.Errorf("format(21.26, %q) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertTemperatures(t *testing.T) {
+	tests := []struct {
+		tempC                       float64
+		wantF, wantK, wantR, wantRe float64
+	}{
+		{0, 32, 273, 491.67, 0},
+		{25, 77, 298, 536.67, 20},
+		{-10, 14, 263, 473.67, -8},
+	}
+
+	h := NewHandler("key", nil, "pt")
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.tempC), func(t *testing.T) {
+			tempF, tempK, tempR, tempRe := h.convertTemperatures(context.Background(), tt.tempC)
+			for _, got := range []struct {
+				unit      string
+				got, want float64
+			}{
+				{"F", tempF, tt.wantF},
+				{"K", tempK, tt.wantK},
+				{"R", tempR, tt.wantR},
+				{"Re", tempRe, tt.wantRe},
+			} {
+				if math.Abs(got.got-got.want) > 1e-9 {
+					t.Errorf("temp_%s(%v) = %v, want %v", got.unit, tt.tempC, got.got, got.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWeatherHandlerImplausibleTemperature(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		wantStatus int
+		wantCode   string
+	}{
+		{"warns by default", false, http.StatusOK, ""},
+		{"rejects in strict mode", true, http.StatusBadGateway, "IMPLAUSIBLE_TEMPERATURE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := recordSpans(t)
+			h, _ := newStubHandler(func(req *http.Request) (int, string) {
+				if req.URL.Host == "weatherapi.test" {
+					return http.StatusOK, `{"current":{"temp_c":75,"condition":{"text":"Sunny"}}}`
+				}
+				return http.StatusOK, `{"cep":"01001-000","localidade":"São Paulo","uf":"SP"}`
+			})
+			h.StrictTempBounds = tt.strict
+
+			rec := serveWeather(h, "cep=01001000")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantCode != "" {
+				var body ErrorResponse
+				json.NewDecoder(rec.Body).Decode(&body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+			}
+
+			var warned bool
+			for _, span := range recorder.Ended() {
+				if span.Name() != "service-b: decode-weather-response" {
+					continue
+				}
+				for _, event := range span.Events() {
+					warned = warned || event.Name == "temperature.suspicious"
+				}
+			}
+			if !warned {
+				t.Error("no temperature.suspicious span event recorded")
+			}
+		})
+	}
+}
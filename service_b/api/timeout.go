@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (h *Handler) withCallTimeout(ctx context.Context, provider string) (context.Context, context.CancelFunc) {
+	timeout := h.ViaCEPTimeout
+	if provider == providerWeatherAPI {
+		timeout = h.WeatherAPITimeout
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (h *Handler) withFlightTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := h.WeatherAPITimeout
+	if timeout <= 0 {
+		timeout = httpx.DefaultRequestTimeout
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func recordTimeout(span trace.Span, err error) {
+	if isTimeout(err) {
+		span.SetAttributes(attribute.String("error.type", "timeout"))
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+const DefaultUFTimezoneTable = "AC:-05:00,AM:-04:00,MT:-04:00,MS:-04:00,RO:-04:00,RR:-04:00," +
+	"AL:-03:00,AP:-03:00,BA:-03:00,CE:-03:00,DF:-03:00,ES:-03:00,GO:-03:00,MA:-03:00,MG:-03:00," +
+	"PA:-03:00,PB:-03:00,PE:-03:00,PI:-03:00,PR:-03:00,RJ:-03:00,RN:-03:00,RS:-03:00,SC:-03:00," +
+	"SE:-03:00,SP:-03:00,TO:-03:00"
+
+func LoadUFTimezones(overrides string) (PrefixTable, error) {
+	timezones, err := ParsePrefixTable(DefaultUFTimezoneTable)
+	if err != nil {
+		return nil, err
+	}
+	if overrides == "" {
+		return timezones, nil
+	}
+
+	extra, err := ParsePrefixTable(overrides)
+	if err != nil {
+		return nil, err
+	}
+	for uf, offset := range extra {
+		timezones[uf] = offset
+	}
+	return timezones, nil
+}
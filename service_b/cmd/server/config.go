@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_b/api"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
+)
+
+type Config struct {
+	WeatherAPIKey string
+	DefaultLang   string
+	Port          string
+	Sandbox       bool
+
+	TracerShutdownTimeout  time.Duration
+	MetricsExportInterval  time.Duration
+	RuntimeMetricsInterval time.Duration
+
+	DialNetwork       string
+	ConnectTimeout    time.Duration
+	MaxConnsPerHost   int
+	SigningSecret     string
+	BudgetPerMinute   int
+	ErrorLogSize      int
+	ViaCEPTimeout     time.Duration
+	WeatherAPITimeout time.Duration
+	MaxRedirects      int
+	Retry             httpx.RetryPolicy
+
+	AllowPrivateUpstreams bool
+
+	ViaCEPBaseURL     string
+	BrasilAPIBaseURL  string
+	WeatherAPIBaseURL string
+
+	TemperatureFormat    api.TemperatureFormat
+	MinPlausibleTempC    float64
+	MaxPlausibleTempC    float64
+	StrictTempBounds     bool
+	OmitUnavailableTemps bool
+
+	CEPCacheTTL           time.Duration
+	WeatherCacheTTL       time.Duration
+	WeatherCacheStaleTTL  time.Duration
+	ServeStaleOnRateLimit bool
+	CacheMaxEntries       int
+	WeatherHistorySize    int
+	WeatherHistoryCities  int
+	StampedeThreshold     int
+	StampedeWindow        time.Duration
+
+	CityFallback     api.PrefixTable
+	ReservedPrefixes []string
+	CEPOverrides     map[string]string
+	Timezones        api.PrefixTable
+	RegionKeys       api.PrefixTable
+
+	NearbyRadiusKm   float64
+	NearbyMaxResults int
+	StreamInterval   time.Duration
+	MaxStreams       int
+
+	AdminToken       string
+	Debug            bool
+	TraceURLTemplate string
+
+	ReadinessGrace   time.Duration
+	Prewarm          bool
+	PrewarmTimeout   time.Duration
+	DrainDelay       time.Duration
+	ServerID         string
+	MaxHops          int
+	CompressionLevel int
+	MaintenanceMode  bool
+	RequestIDHeader  string
+	TenantHeader     string
+}
+
+func loadConfig() (Config, error) {
+	env := &utils.EnvReader{}
+
+	cfg := Config{
+		WeatherAPIKey: os.Getenv("WEATHERAPI_KEY"),
+		DefaultLang:   os.Getenv("WEATHERAPI_LANG"),
+		Port:          env.String("PORT", defaultPort),
+		Sandbox:       env.Bool("SANDBOX_MODE"),
+
+		TracerShutdownTimeout:  env.Duration("OTEL_SHUTDOWN_TIMEOUT", utils.DefaultTracerShutdownTimeout),
+		MetricsExportInterval:  env.Duration("METRICS_EXPORT_INTERVAL", utils.DefaultMetricsExportInterval),
+		RuntimeMetricsInterval: env.Duration("RUNTIME_METRICS_INTERVAL", utils.DefaultRuntimeMetricsInterval),
+
+		DialNetwork:     os.Getenv("DIAL_NETWORK"),
+		ConnectTimeout:  env.Duration("UPSTREAM_CONNECT_TIMEOUT", httpx.DefaultConnectTimeout),
+		MaxConnsPerHost: env.Int("UPSTREAM_MAX_CONNS_PER_HOST", 0),
+		SigningSecret:   os.Getenv("OUTBOUND_SIGNING_SECRET"),
+		BudgetPerMinute: env.Int("UPSTREAM_BUDGET_PER_MINUTE", 0),
+		ErrorLogSize:    env.Int("UPSTREAM_ERROR_LOG_SIZE", httpx.DefaultErrorLogSize),
+		MaxRedirects:    env.Int("MAX_REDIRECTS", defaultMaxRedirect),
+		Retry: httpx.RetryPolicy{
+			MaxRetries:  env.Int("RETRY_MAX_RETRIES", httpx.DefaultRetryMaxRetries),
+			MaxDelay:    env.Duration("RETRY_MAX_DELAY", httpx.DefaultRetryMaxDelay),
+			BaseDelay:   env.Duration("RETRY_BASE_DELAY", httpx.DefaultRetryBaseDelay),
+			RetryErrors: os.Getenv("RETRY_ERRORS"),
+			Jitter:      os.Getenv("RETRY_JITTER"),
+		},
+
+		AllowPrivateUpstreams: env.Bool("ALLOW_PRIVATE_UPSTREAMS"),
+
+		ViaCEPBaseURL:     env.String("VIACEP_BASE_URL", api.DefaultViaCEPBaseURL),
+		BrasilAPIBaseURL:  env.String("BRASILAPI_BASE_URL", api.DefaultBrasilAPIBaseURL),
+		WeatherAPIBaseURL: env.String("WEATHERAPI_BASE_URL", api.DefaultWeatherAPIBaseURL),
+
+		MinPlausibleTempC:    env.Float("TEMP_MIN_C", api.DefaultMinPlausibleTempC),
+		MaxPlausibleTempC:    env.Float("TEMP_MAX_C", api.DefaultMaxPlausibleTempC),
+		StrictTempBounds:     env.Bool("TEMP_STRICT_BOUNDS"),
+		OmitUnavailableTemps: env.Bool("OMIT_UNAVAILABLE_TEMPS"),
+
+		CEPCacheTTL:           env.Duration("CEP_CACHE_TTL", api.DefaultCEPCacheTTL),
+		WeatherCacheTTL:       env.Duration("WEATHER_CACHE_TTL", api.DefaultWeatherCacheTTL),
+		WeatherCacheStaleTTL:  env.Duration("WEATHER_CACHE_STALE_TTL", api.DefaultWeatherCacheStaleTTL),
+		ServeStaleOnRateLimit: env.Bool("SERVE_STALE_ON_RATE_LIMIT"),
+		CacheMaxEntries:       env.Int("CACHE_MAX_ENTRIES", cache.DefaultMaxEntries),
+		WeatherHistorySize:    env.Int("WEATHER_HISTORY_SIZE", api.DefaultWeatherHistorySize),
+		WeatherHistoryCities:  env.Int("WEATHER_HISTORY_CITIES", api.DefaultWeatherHistoryCities),
+		StampedeThreshold:     env.Int("STAMPEDE_THRESHOLD", 0),
+		StampedeWindow:        env.Duration("STAMPEDE_WINDOW", defaultStampedeWindow),
+
+		NearbyRadiusKm:   env.Float("NEARBY_RADIUS_KM", api.DefaultNearbyRadiusKm),
+		NearbyMaxResults: env.Int("NEARBY_MAX_RESULTS", api.DefaultNearbyMaxResults),
+		StreamInterval:   env.Duration("STREAM_INTERVAL", api.DefaultStreamInterval),
+		MaxStreams:       env.Int("MAX_STREAMS", api.DefaultMaxStreams),
+
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+		Debug:      env.Bool("DEBUG_MODE"),
+
+		ReadinessGrace:   env.Duration("READINESS_GRACE_PERIOD", 0),
+		Prewarm:          env.Bool("PREWARM_CONNECTIONS"),
+		PrewarmTimeout:   env.Duration("PREWARM_TIMEOUT", api.DefaultPrewarmTimeout),
+		DrainDelay:       env.Duration("SHUTDOWN_DRAIN_DELAY", 0),
+		ServerID:         httpx.ResolveServerID(os.Getenv("SERVER_ID")),
+		MaxHops:          env.Int("MAX_HOPS", defaultMaxHops),
+		CompressionLevel: env.Int("COMPRESSION_LEVEL", httpx.DefaultCompressionLevel),
+		MaintenanceMode:  env.Bool("MAINTENANCE_MODE"),
+		RequestIDHeader:  os.Getenv("REQUEST_ID_HEADER"),
+		TenantHeader:     os.Getenv("TENANT_HEADER"),
+	}
+	if cfg.Debug {
+		cfg.TraceURLTemplate = os.Getenv("TRACE_URL_TEMPLATE")
+	}
+	upstreamTimeout := env.Duration("UPSTREAM_REQUEST_TIMEOUT", httpx.DefaultRequestTimeout)
+	cfg.ViaCEPTimeout = env.Duration("VIACEP_TIMEOUT", upstreamTimeout)
+	cfg.WeatherAPITimeout = env.Duration("WEATHERAPI_TIMEOUT", upstreamTimeout)
+
+	if cfg.WeatherAPIKey == "" && !cfg.Sandbox {
+		env.Fail(errors.New("WEATHERAPI_KEY environment variable not set"))
+	}
+	if cfg.DefaultLang != "" && !api.IsValidLang(cfg.DefaultLang) {
+		env.Fail(fmt.Errorf("WEATHERAPI_LANG %q is not a supported WeatherAPI language", cfg.DefaultLang))
+	}
+	switch cfg.DialNetwork {
+	case "", httpx.DialNetworkTCP, httpx.DialNetworkTCP4, httpx.DialNetworkTCP6:
+	default:
+		env.Fail(fmt.Errorf("DIAL_NETWORK %q must be one of tcp, tcp4 or tcp6", cfg.DialNetwork))
+	}
+	switch cfg.Retry.RetryErrors {
+	case "", httpx.RetryErrorsNone, httpx.RetryErrorsConnect:
+	default:
+		env.Fail(fmt.Errorf("RETRY_ERRORS %q must be none or connect", cfg.Retry.RetryErrors))
+	}
+	if !httpx.IsValidJitter(cfg.Retry.Jitter) {
+		env.Fail(fmt.Errorf("RETRY_JITTER %q must be one of none, full, equal or decorrelated", cfg.Retry.Jitter))
+	}
+	if cfg.CompressionLevel < 1 || cfg.CompressionLevel > 9 {
+		env.Fail(fmt.Errorf("COMPRESSION_LEVEL must be between 1 and 9, got %d", cfg.CompressionLevel))
+	}
+	if cfg.NearbyRadiusKm <= 0 {
+		env.Fail(fmt.Errorf("NEARBY_RADIUS_KM must be positive, got %g", cfg.NearbyRadiusKm))
+	}
+
+	var err error
+	cfg.TemperatureFormat, err = api.NewTemperatureFormat(env.Int("JSON_TEMPERATURE_PRECISION", api.DefaultTemperaturePrecision), os.Getenv("TEMPERATURE_ROUNDING_MODE"))
+	env.Check("temperature format", err)
+
+	if env.Bool("CEP_FALLBACK_ENABLED") {
+		cfg.CityFallback, err = api.ParsePrefixTable(env.String("CEP_FALLBACK_CITIES", api.DefaultCityFallbackTable))
+		env.Check("CEP_FALLBACK_CITIES", err)
+	}
+	cfg.ReservedPrefixes, err = api.ParseReservedPrefixes(env.String("RESERVED_CEP_PREFIXES", api.DefaultReservedCEPPrefixes))
+	env.Check("RESERVED_CEP_PREFIXES", err)
+	if pairs, file := os.Getenv("CEP_OVERRIDES"), os.Getenv("CEP_OVERRIDES_FILE"); pairs != "" || file != "" {
+		cfg.CEPOverrides, err = api.LoadCEPOverrides(pairs, file)
+		env.Check("CEP overrides", err)
+	}
+	cfg.Timezones, err = api.LoadUFTimezones(os.Getenv("UF_TIMEZONES"))
+	env.Check("UF_TIMEZONES", err)
+	if table := os.Getenv("WEATHERAPI_REGION_KEYS"); table != "" {
+		cfg.RegionKeys, err = api.ParsePrefixTable(table)
+		env.Check("WEATHERAPI_REGION_KEYS", err)
+	}
+
+	if !cfg.Sandbox {
+		allowedHosts := api.DefaultUpstreamHosts
+		if extra := os.Getenv("UPSTREAM_ALLOWED_HOSTS"); extra != "" {
+			allowedHosts = append(allowedHosts, strings.Split(extra, ",")...)
+		}
+		for _, baseURL := range []string{cfg.ViaCEPBaseURL, cfg.BrasilAPIBaseURL, cfg.WeatherAPIBaseURL} {
+			env.Check("upstream URL", httpx.ValidateUpstreamURL(baseURL, allowedHosts, cfg.AllowPrivateUpstreams))
+		}
+	}
+
+	return cfg, env.Err()
+}
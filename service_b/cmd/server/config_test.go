@@ -0,0 +1,94 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigRejectsMalformedEnv(t *testing.T) {
+	t.Setenv("WEATHERAPI_KEY", "test-key")
+	t.Setenv("CEP_CACHE_TTL", "5mins")
+	t.Setenv("MAX_STREAMS", "ten")
+	t.Setenv("TEMP_MIN_C", "cold")
+	t.Setenv("TEMP_STRICT_BOUNDS", "maybe")
+
+	_, err := loadConfig()
+	if err == nil {
+		t.Fatal("loadConfig() = nil, want error for malformed values")
+	}
+	for _, key := range []string{"CEP_CACHE_TTL", "MAX_STREAMS", "TEMP_MIN_C", "TEMP_STRICT_BOUNDS"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("error %q does not mention %s", err, key)
+		}
+	}
+}
+
+func TestLoadConfigParsesValidEnv(t *testing.T) {
+	t.Setenv("WEATHERAPI_KEY", "test-key")
+	t.Setenv("CEP_CACHE_TTL", "5m")
+	t.Setenv("MAX_STREAMS", "10")
+	t.Setenv("TEMP_MIN_C", "-40.5")
+	t.Setenv("TEMP_STRICT_BOUNDS", "true")
+
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatalf("loadConfig() = %v", err)
+	}
+	if cfg.CEPCacheTTL != 5*time.Minute || cfg.MaxStreams != 10 || cfg.MinPlausibleTempC != -40.5 || !cfg.StrictTempBounds {
+		t.Errorf("cfg = %+v, want values from the environment", cfg)
+	}
+	if cfg.Port != defaultPort {
+		t.Errorf("Port = %q, want default %q", cfg.Port, defaultPort)
+	}
+}
+
+func TestLoadConfigRejectsDisallowedUpstreams(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "host outside the allowlist",
+			env:     map[string]string{"VIACEP_BASE_URL": "https://evil.example.com"},
+			wantErr: "not in the allowlist",
+		},
+		{
+			name:    "allowlisted private address",
+			env:     map[string]string{"WEATHERAPI_BASE_URL": "http://10.0.0.5", "UPSTREAM_ALLOWED_HOSTS": "10.0.0.5"},
+			wantErr: "non-public",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WEATHERAPI_KEY", "test-key")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := loadConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadConfig() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigTraceURLTemplateRequiresDebug(t *testing.T) {
+	for _, debug := range []string{"false", "true"} {
+		t.Run("DEBUG_MODE="+debug, func(t *testing.T) {
+			t.Setenv("WEATHERAPI_KEY", "test-key")
+			t.Setenv("DEBUG_MODE", debug)
+			t.Setenv("TRACE_URL_TEMPLATE", "http://jaeger.test/trace/{trace_id}")
+
+			cfg, err := loadConfig()
+			if err != nil {
+				t.Fatalf("loadConfig() = %v", err)
+			}
+			if got := cfg.TraceURLTemplate != ""; got != (debug == "true") {
+				t.Errorf("TraceURLTemplate = %q with DEBUG_MODE=%s", cfg.TraceURLTemplate, debug)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 
 	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/service_b/api"
 	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/cache"
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils/httpx"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
@@ -20,43 +22,147 @@ const (
 	serverReadTimeout  = 10 * time.Second
 	serverWriteTimeout = 10 * time.Second
 	serverIdleTimeout  = 60 * time.Second
+	requestTimeout     = 9 * time.Second
+	defaultMaxHops     = 5
+	defaultMaxRedirect = 3
+
+	defaultStampedeWindow = time.Second
 )
 
 func main() {
-	otelEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if otelEndpoint == "" {
-		log.Panic("OTEL_EXPORTER_OTLP_ENDPOINT environment variable not set")
+	logLevel, err := utils.InitLogger(os.Stdout, "service-b", os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		log.Fatalf("Invalid LOG_LEVEL: %v", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
-	shutdownTracer, err := utils.InitTracer("service-b", otelEndpoint)
+	_, shutdownTracer, err := utils.InitTracerProvider(context.Background(), "service-b")
 	if err != nil {
 		log.Fatalf("Failed to initialize tracer: %v", err)
 	}
-	defer func() {
-		if err := shutdownTracer(context.Background()); err != nil {
-			log.Printf("Error shutting down tracer: %v", err)
-		}
-	}()
 
-	weatherAPIKey := os.Getenv("WEATHERAPI_KEY")
-	if weatherAPIKey == "" {
-		log.Panic("WEATHERAPI_KEY environment variable not set")
+	meterProvider, shutdownMeter, err := utils.InitMeterProvider(context.Background(), "service-b", cfg.MetricsExportInterval)
+	if err != nil {
+		log.Fatalf("Failed to initialize meter provider: %v", err)
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	metricsCtx, stopMetrics := context.WithCancel(context.Background())
+	defer stopMetrics()
+	if err := utils.StartRuntimeMetrics(metricsCtx, meterProvider, cfg.RuntimeMetricsInterval); err != nil {
+		log.Printf("Failed to start runtime metrics: %v", err)
 	}
 
+	dialTransport, err := httpx.NewTransport(cfg.DialNetwork, cfg.ConnectTimeout, cfg.AllowPrivateUpstreams)
+	if err != nil {
+		log.Fatalf("Failed to create upstream transport: %v", err)
+	}
+	httpx.LimitConnsPerHost(dialTransport, cfg.MaxConnsPerHost)
+
+	upstreamTransport := httpx.NewSigningTransport(api.NewAPIKeyTransport(dialTransport), cfg.SigningSecret)
+	if cfg.BudgetPerMinute > 0 {
+		upstreamTransport = api.NewBudgetTransport(upstreamTransport, api.NewSlidingWindowBudget(cfg.BudgetPerMinute, time.Minute))
+	}
+
+	errorLog := httpx.NewErrorLog(cfg.ErrorLogSize)
+	upstreamTransport = httpx.NewErrorLogTransport(upstreamTransport, errorLog)
+
 	httpClient := &http.Client{
-		Timeout:   5 * time.Second,
-		Transport: otelhttp.NewTransport(http.DefaultTransport),
+		Transport: httpx.NewRetryTransport(
+			otelhttp.NewTransport(httpx.CountingTransport(upstreamTransport)),
+			cfg.Retry,
+		),
+		CheckRedirect: httpx.CheckRedirect(cfg.MaxRedirects),
 	}
-	handler := api.NewHandler(weatherAPIKey, httpClient)
-	router := api.SetupRouter(handler)
+
+	handler := api.NewHandler(cfg.WeatherAPIKey, httpClient, cfg.DefaultLang)
+	handler.Readiness = httpx.NewReadiness(cfg.ReadinessGrace)
+	handler.ErrorLog = errorLog
+	handler.MaxRetries = cfg.Retry.MaxRetries
+	handler.RetryBaseDelay = cfg.Retry.BaseDelay
+	handler.TemperatureFormat = cfg.TemperatureFormat
+	if cfg.CEPCacheTTL > 0 {
+		handler.CEPCache = cache.NewTTLCache[api.ViaCEPResponse](cfg.CEPCacheTTL)
+	}
+	if cfg.WeatherCacheTTL > 0 {
+		handler.WeatherCache = cache.NewTTLCache[api.CurrentWeather](cfg.WeatherCacheTTL)
+	}
+	if cfg.ServeStaleOnRateLimit && handler.WeatherCache != nil {
+		handler.ServeStaleOnRateLimit = true
+		handler.WeatherCache.StaleTTL = cfg.WeatherCacheStaleTTL
+	}
+	if handler.CEPCache != nil {
+		handler.CEPCache.MaxEntries = cfg.CacheMaxEntries
+		handler.CEPCache.StartCleanup(metricsCtx, cache.DefaultCleanupInterval)
+	}
+	if handler.WeatherCache != nil {
+		handler.WeatherCache.MaxEntries = cfg.CacheMaxEntries
+		handler.WeatherCache.StartCleanup(metricsCtx, cache.DefaultCleanupInterval)
+	}
+	handler.OmitUnavailableTemps = cfg.OmitUnavailableTemps
+	if cfg.WeatherHistorySize > 0 {
+		handler.History = api.NewWeatherHistory(cfg.WeatherHistorySize)
+		handler.History.MaxCities = cfg.WeatherHistoryCities
+	}
+	handler.AdminToken = cfg.AdminToken
+	handler.LogLevel = logLevel
+	handler.Debug = cfg.Debug
+	handler.TraceURLTemplate = cfg.TraceURLTemplate
+	handler.NearbyMaxResults = cfg.NearbyMaxResults
+	handler.NearbyRadiusKm = cfg.NearbyRadiusKm
+	handler.ViaCEPTimeout = cfg.ViaCEPTimeout
+	handler.WeatherAPITimeout = cfg.WeatherAPITimeout
+	handler.StreamInterval = cfg.StreamInterval
+	handler.MaxStreams = cfg.MaxStreams
+	handler.MinPlausibleTempC = cfg.MinPlausibleTempC
+	handler.MaxPlausibleTempC = cfg.MaxPlausibleTempC
+	handler.StrictTempBounds = cfg.StrictTempBounds
+
+	if cfg.Sandbox {
+		log.Println("**********************************************************")
+		log.Println("* SANDBOX MODE ENABLED: returning canned weather data and *")
+		log.Println("* NOT calling ViaCEP or WeatherAPI                       *")
+		log.Println("**********************************************************")
+		handler.Sandbox = true
+	}
+
+	if cfg.StampedeThreshold > 0 {
+		handler.Stampede = api.NewStampedeDetector(cfg.StampedeThreshold, cfg.StampedeWindow)
+		handler.Stampede.StartCleanup(metricsCtx, api.DefaultStampedeCleanupInterval)
+	}
+
+	handler.CityFallback = cfg.CityFallback
+	handler.ReservedPrefixes = cfg.ReservedPrefixes
+	handler.CEPOverrides = cfg.CEPOverrides
+	handler.Timezones = cfg.Timezones
+	handler.RegionKeys = cfg.RegionKeys
+	handler.ViaCEPBaseURL = cfg.ViaCEPBaseURL
+	handler.BrasilAPIBaseURL = cfg.BrasilAPIBaseURL
+	handler.WeatherAPIBaseURL = cfg.WeatherAPIBaseURL
+
+	if !handler.Sandbox {
+		handler.Readiness.AddCheck("viacep", handler.CheckViaCEP)
+	}
+
+	if cfg.Prewarm && !handler.Sandbox {
+		handler.Prewarm(context.Background(), cfg.PrewarmTimeout)
+	}
+
+	router := api.SetupRouter(handler, httpx.Config{
+		ServerID:         cfg.ServerID,
+		Timeout:          requestTimeout,
+		MaxHops:          cfg.MaxHops,
+		CompressionLevel: cfg.CompressionLevel,
+		MaintenanceMode:  cfg.MaintenanceMode,
+		RequestIDHeader:  cfg.RequestIDHeader,
+		TenantHeader:     cfg.TenantHeader,
+	})
 
 	server := &http.Server{
-		Addr:         ":" + port,
+		Addr:         ":" + cfg.Port,
 		Handler:      router,
 		ReadTimeout:  serverReadTimeout,
 		WriteTimeout: serverWriteTimeout,
@@ -66,7 +172,7 @@ func main() {
 	serverErrors := make(chan error, 1)
 
 	go func() {
-		log.Printf("Service B starting on port %s", port)
+		log.Printf("Service B starting on port %s", cfg.Port)
 		serverErrors <- server.ListenAndServe()
 	}()
 
@@ -79,6 +185,12 @@ func main() {
 	case sig := <-shutdown:
 		log.Printf("Received signal %v, shutting down gracefully...", sig)
 
+		handler.Readiness.MarkShuttingDown()
+		if cfg.DrainDelay > 0 {
+			log.Printf("Readiness marked down, waiting %s before draining connections", cfg.DrainDelay)
+			time.Sleep(cfg.DrainDelay)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer cancel()
 
@@ -87,6 +199,17 @@ func main() {
 			server.Close()
 		}
 
+		flushTimeout := cfg.TracerShutdownTimeout
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < flushTimeout {
+			flushTimeout = time.Until(deadline)
+		}
+		if err := utils.ShutdownTracer(shutdownTracer, flushTimeout); err != nil {
+			log.Printf("Error shutting down tracer: %v", err)
+		}
+		if err := shutdownMeter(ctx); err != nil {
+			log.Printf("Error shutting down meter provider: %v", err)
+		}
+
 		log.Println("Service B stopped")
 	}
 }
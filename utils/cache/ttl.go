@@ -0,0 +1,200 @@
+package cache
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultMaxEntries      = 10000
+	DefaultCleanupInterval = time.Minute
+)
+
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+	index     int
+}
+
+// expiryHeap orders entries by expiry so eviction and cleanup only touch the
+// entries that are due instead of scanning the whole map.
+type expiryHeap[V any] []*entry[V]
+
+func (h expiryHeap[V]) Len() int           { return len(h) }
+func (h expiryHeap[V]) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+
+func (h expiryHeap[V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap[V]) Push(x any) {
+	e := x.(*entry[V])
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expiryHeap[V]) Pop() any {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+type Stats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+type TTLCache[V any] struct {
+	ttl time.Duration
+
+	StaleTTL   time.Duration
+	MaxEntries int
+
+	mu      sync.RWMutex
+	entries map[string]*entry[V]
+	expiry  expiryHeap[V]
+	now     func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func NewTTLCache[V any](ttl time.Duration) *TTLCache[V] {
+	return &TTLCache[V]{ttl: ttl, entries: make(map[string]*entry[V]), now: time.Now}
+}
+
+func (c *TTLCache[V]) Get(key string) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	var value V
+	var expiresAt time.Time
+	if ok {
+		value, expiresAt = e.value, e.expiresAt
+	}
+	c.mu.RUnlock()
+	if !ok {
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	if !c.now().Before(expiresAt) {
+		c.mu.Lock()
+		if current, ok := c.entries[key]; ok && !c.now().Before(current.expiresAt.Add(c.StaleTTL)) {
+			c.remove(current)
+		}
+		c.mu.Unlock()
+		c.misses.Add(1)
+		return zero, false
+	}
+	c.hits.Add(1)
+	return value, true
+}
+
+func (c *TTLCache[V]) GetStale(key string) (V, bool) {
+	var zero V
+	if c == nil {
+		return zero, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt.Add(c.StaleTTL)) {
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *TTLCache[V]) Set(key string, value V) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := c.now().Add(c.ttl)
+	if e, exists := c.entries[key]; exists {
+		e.value, e.expiresAt = value, expiresAt
+		heap.Fix(&c.expiry, e.index)
+		return
+	}
+	if c.MaxEntries > 0 && len(c.entries) >= c.MaxEntries {
+		c.remove(c.expiry[0])
+	}
+	e := &entry[V]{key: key, value: value, expiresAt: expiresAt}
+	heap.Push(&c.expiry, e)
+	c.entries[key] = e
+}
+
+// remove drops e from both the map and the expiry heap; the caller holds mu.
+func (c *TTLCache[V]) remove(e *entry[V]) {
+	heap.Remove(&c.expiry, e.index)
+	delete(c.entries, e.key)
+}
+
+func (c *TTLCache[V]) Cleanup() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	now := c.now()
+	for len(c.expiry) > 0 && !now.Before(c.expiry[0].expiresAt.Add(c.StaleTTL)) {
+		c.remove(c.expiry[0])
+		removed++
+	}
+	return removed
+}
+
+func (c *TTLCache[V]) StartCleanup(ctx context.Context, interval time.Duration) {
+	if c == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Cleanup()
+			}
+		}
+	}()
+}
+
+func (c *TTLCache[V]) Len() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+func (c *TTLCache[V]) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	return Stats{Entries: c.Len(), Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
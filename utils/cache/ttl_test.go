@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheMaxEntries(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewTTLCache[int](time.Minute)
+	c.now = func() time.Time { return now }
+	c.MaxEntries = 2
+
+	c.Set("a", 1)
+	now = now.Add(2 * time.Minute)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	if got := c.Len(); got != 2 {
+		t.Fatalf("Len() = %d, want 2", got)
+	}
+	if _, ok := c.GetStale("a"); ok {
+		t.Error("expired entry a should have been evicted first")
+	}
+	for _, key := range []string{"b", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Get(%q) missed, want hit", key)
+		}
+	}
+
+	c.Set("b", 20)
+	if got := c.Len(); got != 2 {
+		t.Fatalf("Len() after overwrite = %d, want 2", got)
+	}
+}
+
+func TestTTLCacheCleanup(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewTTLCache[int](time.Minute)
+	c.now = func() time.Time { return now }
+	c.StaleTTL = time.Minute
+
+	c.Set("old", 1)
+	now = now.Add(90 * time.Second)
+	c.Set("new", 2)
+
+	if removed := c.Cleanup(); removed != 0 {
+		t.Fatalf("Cleanup() within stale window removed %d, want 0", removed)
+	}
+
+	now = now.Add(time.Minute)
+	if removed := c.Cleanup(); removed != 1 {
+		t.Fatalf("Cleanup() removed %d, want 1", removed)
+	}
+	if _, ok := c.GetStale("new"); !ok {
+		t.Error("entry still inside its stale window should survive cleanup")
+	}
+}
+
+func TestTTLCacheStats(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewTTLCache[int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Get("a")
+	c.Set("a", 1)
+	c.Get("a")
+	c.Get("a")
+	now = now.Add(2 * time.Minute)
+	c.Get("a")
+
+	if got, want := c.Stats(), (Stats{Entries: 0, Hits: 2, Misses: 2}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestTTLCacheEvictsSoonestExpiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewTTLCache[int](time.Minute)
+	c.now = func() time.Time { return now }
+	c.MaxEntries = 3
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, 1)
+		now = now.Add(time.Second)
+	}
+	c.Set("a", 2)
+	c.Set("d", 4)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b expires first and should have been evicted")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("Get(%q) missed, want hit", key)
+		}
+	}
+	if got, _ := c.Get("a"); got != 2 {
+		t.Errorf("Get(a) = %d, want the refreshed value 2", got)
+	}
+}
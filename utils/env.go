@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EnvReader reads typed environment variables and collects every malformed
+// value instead of silently falling back, so a service can report all of its
+// configuration errors at once.
+type EnvReader struct {
+	errs []error
+}
+
+func (e *EnvReader) Fail(err error) {
+	e.errs = append(e.errs, err)
+}
+
+func (e *EnvReader) Check(name string, err error) {
+	if err != nil {
+		e.Fail(fmt.Errorf("invalid %s: %w", name, err))
+	}
+}
+
+func (e *EnvReader) Err() error {
+	return errors.Join(e.errs...)
+}
+
+func (e *EnvReader) String(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (e *EnvReader) Int(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		e.Fail(fmt.Errorf("%s %q is not an integer", key, raw))
+		return fallback
+	}
+	return v
+}
+
+func (e *EnvReader) Float(key string, fallback float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		e.Fail(fmt.Errorf("%s %q is not a number", key, raw))
+		return fallback
+	}
+	return v
+}
+
+func (e *EnvReader) Duration(key string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := time.ParseDuration(raw)
+	if err != nil {
+		e.Fail(fmt.Errorf("%s %q is not a duration (e.g. 30s, 5m)", key, raw))
+		return fallback
+	}
+	return v
+}
+
+func (e *EnvReader) Bool(key string) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return false
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		e.Fail(fmt.Errorf("%s %q is not a boolean", key, raw))
+		return false
+	}
+	return v
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvReader(t *testing.T) {
+	t.Setenv("TEST_INT", "7")
+	t.Setenv("TEST_BAD_INT", "seven")
+	t.Setenv("TEST_DURATION", "5m")
+	t.Setenv("TEST_BAD_DURATION", "5mins")
+	t.Setenv("TEST_BOOL", "true")
+	t.Setenv("TEST_BAD_BOOL", "yes please")
+
+	env := &EnvReader{}
+	if got := env.Int("TEST_INT", 1); got != 7 {
+		t.Errorf("Int = %d, want 7", got)
+	}
+	if got := env.Int("TEST_UNSET", 1); got != 1 {
+		t.Errorf("Int(unset) = %d, want the fallback", got)
+	}
+	if got := env.Duration("TEST_DURATION", time.Second); got != 5*time.Minute {
+		t.Errorf("Duration = %s, want 5m", got)
+	}
+	if !env.Bool("TEST_BOOL") {
+		t.Error("Bool = false, want true")
+	}
+	if err := env.Err(); err != nil {
+		t.Fatalf("Err() = %v after valid values", err)
+	}
+
+	env.Int("TEST_BAD_INT", 1)
+	env.Duration("TEST_BAD_DURATION", time.Second)
+	env.Bool("TEST_BAD_BOOL")
+	err := env.Err()
+	for _, key := range []string{"TEST_BAD_INT", "TEST_BAD_DURATION", "TEST_BAD_BOOL"} {
+		if err == nil || !strings.Contains(err.Error(), key) {
+			t.Errorf("Err() = %v, want it to mention %s", err, key)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package httpx
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+func RequireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				writeJSON(w, map[string]string{"code": "NOT_FOUND", "message": "not found"}, http.StatusNotFound)
+				return
+			}
+
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				writeJSON(w, map[string]string{"code": "UNAUTHORIZED", "message": "unauthorized"}, http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
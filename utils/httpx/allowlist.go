@@ -0,0 +1,48 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+var ErrNonPublicAddress = errors.New("non-public upstream address")
+
+func ValidateUpstreamURL(rawURL string, allowedHosts []string, allowPrivate bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid upstream url %q: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("upstream url %q must use http or https", rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	allowed := false
+	for _, h := range allowedHosts {
+		if strings.EqualFold(strings.TrimSpace(h), host) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("upstream host %q is not in the allowlist", host)
+	}
+
+	if allowPrivate {
+		return nil
+	}
+
+	// Host names are resolved again on every dial, so their addresses are
+	// checked by the transport (see NewTransport) rather than once here.
+	if ip := net.ParseIP(host); ip != nil && isNonPublic(ip) {
+		return fmt.Errorf("%w: upstream host %q is %s", ErrNonPublicAddress, host, ip)
+	}
+	return nil
+}
+
+func isNonPublic(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
@@ -0,0 +1,91 @@
+package httpx
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	JitterNone         = "none"
+	JitterFull         = "full"
+	JitterEqual        = "equal"
+	JitterDecorrelated = "decorrelated"
+)
+
+func IsValidJitter(strategy string) bool {
+	switch strategy {
+	case "", JitterNone, JitterFull, JitterEqual, JitterDecorrelated:
+		return true
+	default:
+		return false
+	}
+}
+
+type Backoff struct {
+	Strategy  string
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func NewBackoff(strategy string, baseDelay, maxDelay time.Duration, rng *rand.Rand) *Backoff {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &Backoff{Strategy: strategy, BaseDelay: baseDelay, MaxDelay: maxDelay, rng: rng}
+}
+
+func (b *Backoff) Delay(attempt int, previous time.Duration) time.Duration {
+	return b.DelayFrom(b.BaseDelay, attempt, previous)
+}
+
+func (b *Backoff) DelayFrom(base time.Duration, attempt int, previous time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	switch b.Strategy {
+	case JitterNone:
+		return b.ceiling(base, attempt)
+	case JitterEqual:
+		ceiling := b.ceiling(base, attempt)
+		return ceiling/2 + b.between(0, ceiling-ceiling/2)
+	case JitterDecorrelated:
+		if previous < base {
+			previous = base
+		}
+		return b.clamp(b.between(base, previous*3))
+	default:
+		return b.between(0, b.ceiling(base, attempt))
+	}
+}
+
+func (b *Backoff) ceiling(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if b.MaxDelay > 0 && delay >= b.MaxDelay {
+			return b.MaxDelay
+		}
+	}
+	return b.clamp(delay)
+}
+
+func (b *Backoff) clamp(delay time.Duration) time.Duration {
+	if b.MaxDelay > 0 && delay > b.MaxDelay {
+		return b.MaxDelay
+	}
+	return delay
+}
+
+func (b *Backoff) between(low, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return low + time.Duration(b.rng.Int63n(int64(high-low)))
+}
@@ -0,0 +1,71 @@
+package httpx
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBackoffDefaultsToFullJitter(t *testing.T) {
+	const base = 100 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		strategy  string
+		wantFixed bool
+	}{
+		{"unset strategy jitters", "", false},
+		{"full jitter", JitterFull, false},
+		{"none is exact", JitterNone, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBackoff(tt.strategy, base, time.Second, rand.New(rand.NewSource(1)))
+			for attempt := 1; attempt <= 4; attempt++ {
+				ceiling := b.ceiling(base, attempt)
+				distinct := map[time.Duration]bool{}
+				for range 20 {
+					delay := b.Delay(attempt, 0)
+					if delay < 0 || delay > ceiling {
+						t.Fatalf("attempt %d delay %s outside [0, %s]", attempt, delay, ceiling)
+					}
+					distinct[delay] = true
+				}
+				if fixed := len(distinct) == 1 && distinct[ceiling]; fixed != tt.wantFixed {
+					t.Errorf("attempt %d distinct delays = %d, want fixed %v", attempt, len(distinct), tt.wantFixed)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffEqualJitterBounds(t *testing.T) {
+	const base = 100 * time.Millisecond
+	b := NewBackoff(JitterEqual, base, time.Second, rand.New(rand.NewSource(1)))
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		ceiling := b.ceiling(base, attempt)
+		for range 50 {
+			if delay := b.Delay(attempt, 0); delay < ceiling/2 || delay > ceiling {
+				t.Fatalf("attempt %d delay %s outside [%s, %s]", attempt, delay, ceiling/2, ceiling)
+			}
+		}
+	}
+}
+
+func TestBackoffDecorrelatedJitterBounds(t *testing.T) {
+	const base = 100 * time.Millisecond
+	const maxDelay = time.Second
+	b := NewBackoff(JitterDecorrelated, base, maxDelay, rand.New(rand.NewSource(1)))
+
+	var previous time.Duration
+	for attempt := 1; attempt <= 50; attempt++ {
+		high := max(previous, base) * 3
+		delay := b.Delay(attempt, previous)
+		if delay < base || delay > min(high, maxDelay) {
+			t.Fatalf("attempt %d delay %s outside [%s, %s] after %s", attempt, delay, base, min(high, maxDelay), previous)
+		}
+		previous = delay
+	}
+}
@@ -0,0 +1,49 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const DefaultMaxDecompressedBody = 1 << 20
+
+func DecompressBody(maxBytes int64) func(http.Handler) http.Handler {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDecompressedBody
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.EqualFold(strings.TrimSpace(r.Header.Get("Content-Encoding")), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				writeJSON(w, map[string]string{"code": "INVALID_GZIP_BODY", "message": "invalid gzip body"}, http.StatusBadRequest)
+				return
+			}
+			defer reader.Close()
+
+			body, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+			if err != nil {
+				writeJSON(w, map[string]string{"code": "INVALID_GZIP_BODY", "message": "invalid gzip body"}, http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > maxBytes {
+				writeJSON(w, map[string]string{"code": "BODY_TOO_LARGE", "message": "decompressed body too large"}, http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			r.Header.Del("Content-Encoding")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
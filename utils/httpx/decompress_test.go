@@ -0,0 +1,76 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	valid := gzipBytes(t, `{"cep":"01001000"}`)
+	tests := []struct {
+		name       string
+		body       []byte
+		wantStatus int
+		wantCode   string
+		wantBody   string
+	}{
+		{"valid body", valid, http.StatusOK, "", `{"cep":"01001000"}`},
+		{"not gzip", []byte("plain text"), http.StatusBadRequest, "INVALID_GZIP_BODY", ""},
+		{"truncated stream", valid[:len(valid)-6], http.StatusBadRequest, "INVALID_GZIP_BODY", ""},
+		{"over the size cap", gzipBytes(t, strings.Repeat("a", 65)), http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := DecompressBody(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = string(body)
+				if r.Header.Get("Content-Encoding") != "" {
+					t.Error("Content-Encoding still set after decompression")
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got != tt.wantBody {
+				t.Errorf("body seen by handler = %q, want %q", got, tt.wantBody)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var resp map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp["code"] != tt.wantCode {
+				t.Errorf("code = %q, want %q", resp["code"], tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const DefaultErrorLogSize = 20
+
+type UpstreamError struct {
+	Time    time.Time `json:"time"`
+	Method  string    `json:"method"`
+	URL     string    `json:"url"`
+	Status  int       `json:"status,omitempty"`
+	Message string    `json:"message"`
+}
+
+type errorRing struct {
+	entries []UpstreamError
+	next    int
+	full    bool
+}
+
+type ErrorLog struct {
+	mu    sync.Mutex
+	size  int
+	rings map[string]*errorRing
+}
+
+func NewErrorLog(size int) *ErrorLog {
+	if size <= 0 {
+		size = DefaultErrorLogSize
+	}
+	return &ErrorLog{size: size, rings: map[string]*errorRing{}}
+}
+
+func (l *ErrorLog) Record(upstream string, entry UpstreamError) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ring, ok := l.rings[upstream]
+	if !ok {
+		ring = &errorRing{entries: make([]UpstreamError, l.size)}
+		l.rings[upstream] = ring
+	}
+	ring.entries[ring.next] = entry
+	ring.next = (ring.next + 1) % l.size
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+func (l *ErrorLog) Snapshot() map[string][]UpstreamError {
+	if l == nil {
+		return map[string][]UpstreamError{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snapshot := make(map[string][]UpstreamError, len(l.rings))
+	for upstream, ring := range l.rings {
+		var entries []UpstreamError
+		if ring.full {
+			entries = append(entries, ring.entries[ring.next:]...)
+		}
+		entries = append(entries, ring.entries[:ring.next]...)
+		snapshot[upstream] = entries
+	}
+	return snapshot
+}
+
+func (l *ErrorLog) Handler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, l.Snapshot(), http.StatusOK)
+}
+
+func redactURL(req *http.Request) string {
+	redacted := *req.URL
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.ForceQuery = false
+	return redacted.String()
+}
+
+var urlQueryPattern = regexp.MustCompile(`(https?://[^\s"?]*)\?[^\s"]*`)
+
+// redactError drops query strings from any URL in err's message, since
+// upstream credentials such as API keys travel in the query.
+func redactError(err error) string {
+	return urlQueryPattern.ReplaceAllString(err.Error(), "$1")
+}
+
+type errorLogTransport struct {
+	next http.RoundTripper
+	log  *ErrorLog
+}
+
+func NewErrorLogTransport(next http.RoundTripper, log *ErrorLog) http.RoundTripper {
+	return &errorLogTransport{next: next, log: log}
+}
+
+func (t *errorLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+
+	entry := UpstreamError{Time: time.Now(), Method: req.Method, URL: redactURL(req)}
+	switch {
+	case err != nil:
+		entry.Message = redactError(err)
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		entry.Status = resp.StatusCode
+		entry.Message = fmt.Sprintf("upstream returned status %d", resp.StatusCode)
+	default:
+		return resp, err
+	}
+
+	t.log.Record(req.URL.Host, entry)
+	return resp, err
+}
@@ -0,0 +1,58 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorLogKeepsLastErrorsPerUpstream(t *testing.T) {
+	log := NewErrorLog(3)
+	stub := &stubRoundTripper{respond: func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "viacep.test" {
+			return nil, errors.New("connection refused")
+		}
+		return statusResponse(http.StatusBadGateway)
+	}}
+	transport := NewErrorLogTransport(stub, log)
+
+	for i := range 5 {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://weather.test/v1/current.json?q=%d&key=secret", i), nil)
+		transport.RoundTrip(req)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://viacep.test/ws/01001000/json/", nil)
+	transport.RoundTrip(req)
+
+	rec := httptest.NewRecorder()
+	log.Handler(rec, httptest.NewRequest(http.MethodGet, "/admin/errors", nil))
+
+	var got map[string][]UpstreamError
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got["weather.test"]) != 3 {
+		t.Fatalf("weather.test entries = %d, want the last 3", len(got["weather.test"]))
+	}
+	for _, entry := range got["weather.test"] {
+		if entry.Status != http.StatusBadGateway || entry.URL != "http://weather.test/v1/current.json" {
+			t.Errorf("entry = %+v, want a 502 with the query redacted", entry)
+		}
+	}
+	if entries := got["viacep.test"]; len(entries) != 1 || entries[0].Message != "connection refused" {
+		t.Errorf("viacep.test entries = %+v, want the connection error", entries)
+	}
+
+	// The ring keeps insertion order, so the oldest kept entry comes first.
+	for i := range 6 {
+		log.Record("order.test", UpstreamError{Status: 500 + i})
+	}
+	snapshot := log.Snapshot()["order.test"]
+	for i, entry := range snapshot {
+		if want := 503 + i; entry.Status != want {
+			t.Errorf("order.test[%d] = %d, want %d", i, entry.Status, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const HopCountHeader = "X-Hop-Count"
+
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+type hopCountKey struct{}
+
+func CheckRedirect(maxRedirects int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("%w: stopped after %d redirects to %s", ErrTooManyRedirects, len(via), req.URL.Host)
+		}
+		return nil
+	}
+}
+
+func HopLimit(maxHops int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hops, _ := strconv.Atoi(r.Header.Get(HopCountHeader))
+			if hops > maxHops {
+				writeJSON(w, map[string]string{"code": "TOO_MANY_HOPS", "message": "too many hops"}, http.StatusLoopDetected)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), hopCountKey{}, hops)))
+		})
+	}
+}
+
+func HopCount(ctx context.Context) int {
+	hops, _ := ctx.Value(hopCountKey{}).(int)
+	return hops
+}
+
+func SetNextHop(ctx context.Context, req *http.Request) {
+	req.Header.Set(HopCountHeader, strconv.Itoa(HopCount(ctx)+1))
+}
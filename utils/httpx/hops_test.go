@@ -0,0 +1,93 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestHopLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		hops       string
+		wantStatus int
+		wantCode   string
+		wantNext   string
+	}{
+		{"first hop", "", http.StatusOK, "", "1"},
+		{"at the limit", "2", http.StatusOK, "", "3"},
+		{"over the limit", "3", http.StatusLoopDetected, "TOO_MANY_HOPS", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var next string
+			handler := HopLimit(2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				out, _ := http.NewRequest(http.MethodGet, "http://service-b.test", nil)
+				SetNextHop(r.Context(), out)
+				next = out.Header.Get(HopCountHeader)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.hops != "" {
+				req.Header.Set(HopCountHeader, tt.hops)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if next != tt.wantNext {
+				t.Errorf("next hop = %q, want %q", next, tt.wantNext)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body["code"] != tt.wantCode {
+				t.Errorf("code = %q, want %q", body["code"], tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheckRedirect(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		if n < 3 {
+			http.Redirect(w, r, server.URL+"/?n="+strconv.Itoa(n+1), http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		maxRedirects int
+		wantErr      error
+	}{
+		{"within the limit", 5, nil},
+		{"over the limit", 2, ErrTooManyRedirects},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{CheckRedirect: CheckRedirect(tt.maxRedirects)}
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
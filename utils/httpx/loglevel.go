@@ -0,0 +1,40 @@
+package httpx
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/carlosfiori/pos-go-fullcycle-desafio-otel/utils"
+)
+
+type LogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func LogLevelHandler(level *slog.LevelVar) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if level == nil {
+			writeJSON(w, map[string]string{"code": "NOT_FOUND", "message": "not found"}, http.StatusNotFound)
+			return
+		}
+
+		var req LogLevelRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+			writeJSON(w, map[string]string{"code": "INVALID_REQUEST", "message": "invalid request body"}, http.StatusBadRequest)
+			return
+		}
+
+		parsed, err := utils.ParseLogLevel(req.Level)
+		if err != nil || strings.TrimSpace(req.Level) == "" {
+			writeJSON(w, map[string]string{"code": "INVALID_LOG_LEVEL", "message": "level must be one of debug, info, warn, error"}, http.StatusUnprocessableEntity)
+			return
+		}
+
+		previous := level.Level()
+		level.Set(parsed)
+		slog.WarnContext(r.Context(), "log level changed", "from", previous.String(), "to", parsed.String(), "remote", r.RemoteAddr)
+		writeJSON(w, map[string]string{"level": strings.ToLower(parsed.String())}, http.StatusOK)
+	}
+}
@@ -0,0 +1,72 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogLevelHandlerEnablesDebugLogs(t *testing.T) {
+	level := new(slog.LevelVar)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level}))
+	handler := RequireToken("secret")(LogLevelHandler(level))
+
+	logger.DebugContext(context.Background(), "before change")
+	if strings.Contains(buf.String(), "before change") {
+		t.Fatalf("debug log written at the default level: %s", buf.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/loglevel", strings.NewReader(`{"level":"debug"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	logger.DebugContext(context.Background(), "after change")
+	if !strings.Contains(buf.String(), "after change") {
+		t.Errorf("debug log missing after switching to debug: %s", buf.String())
+	}
+}
+
+func TestLogLevelHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		auth       string
+		level      *slog.LevelVar
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"admin disabled", "", "Bearer secret", new(slog.LevelVar), `{"level":"debug"}`, http.StatusNotFound, "NOT_FOUND"},
+		{"wrong token", "secret", "Bearer nope", new(slog.LevelVar), `{"level":"debug"}`, http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"no level var", "secret", "Bearer secret", nil, `{"level":"debug"}`, http.StatusNotFound, "NOT_FOUND"},
+		{"malformed body", "secret", "Bearer secret", new(slog.LevelVar), `{`, http.StatusBadRequest, "INVALID_REQUEST"},
+		{"unknown level", "secret", "Bearer secret", new(slog.LevelVar), `{"level":"verbose"}`, http.StatusUnprocessableEntity, "INVALID_LOG_LEVEL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/loglevel", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", tt.auth)
+			rec := httptest.NewRecorder()
+			RequireToken(tt.token)(LogLevelHandler(tt.level)).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body map[string]string
+			json.NewDecoder(rec.Body).Decode(&body)
+			if body["code"] != tt.wantCode || body["message"] == "" {
+				t.Errorf("body = %v, want code %s and a message", body, tt.wantCode)
+			}
+		})
+	}
+}
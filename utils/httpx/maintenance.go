@@ -0,0 +1,17 @@
+package httpx
+
+import "net/http"
+
+const MaintenanceMessage = "service under maintenance, please try again later"
+
+func Maintenance(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			writeJSON(w, map[string]string{"code": "MAINTENANCE", "message": MaintenanceMessage}, http.StatusServiceUnavailable)
+		})
+	}
+}
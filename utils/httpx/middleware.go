@@ -0,0 +1,92 @@
+package httpx
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	ServerIDHeader    = "X-Server-Id"
+	ProcessedAtHeader = "X-Processed-At"
+)
+
+func ResolveServerID(configured string) string {
+	if configured != "" {
+		return configured
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "unknown"
+	}
+	return hostname
+}
+
+func ServerInfo(serverID string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(ServerIDHeader, serverID)
+			w.Header().Set(ProcessedAtHeader, time.Now().UTC().Format(time.RFC3339))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+const DefaultCompressionLevel = 5
+
+type Config struct {
+	ServerID         string
+	Timeout          time.Duration
+	MaxHops          int
+	CompressionLevel int
+	MaintenanceMode  bool
+	RequestIDHeader  string
+	TenantHeader     string
+
+	MaxDecompressedBody int64
+}
+
+var ProbePaths = []string{"/healthz", "/readyz"}
+
+var StreamPaths = []string{"/weather/stream"}
+
+func skipProbes(mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return skipPaths(ProbePaths, mw)
+}
+
+func skipPaths(paths []string, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+func DefaultMiddleware(cfg Config) []func(http.Handler) http.Handler {
+	mws := []func(http.Handler) http.Handler{
+		NormalizePath,
+		skipProbes(Summary),
+		middleware.Recoverer,
+		RequestID(cfg.RequestIDHeader),
+		Tenant(cfg.TenantHeader),
+		middleware.RealIP,
+		skipPaths(StreamPaths, Timeout(cfg.Timeout)),
+		ServerInfo(cfg.ServerID),
+	}
+	if cfg.CompressionLevel > 0 {
+		mws = append(mws, middleware.Compress(cfg.CompressionLevel))
+	}
+	if cfg.MaxHops > 0 {
+		mws = append(mws, HopLimit(cfg.MaxHops))
+	}
+	return mws
+}
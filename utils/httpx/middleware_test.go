@@ -0,0 +1,116 @@
+package httpx
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestServerInfo(t *testing.T) {
+	before := time.Now().UTC().Truncate(time.Second)
+	handler := ServerInfo("node-1")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get(ServerIDHeader); got != "node-1" {
+		t.Errorf("%s = %q, want node-1", ServerIDHeader, got)
+	}
+	processedAt, err := time.Parse(time.RFC3339, rec.Header().Get(ProcessedAtHeader))
+	if err != nil {
+		t.Fatalf("%s is not RFC3339: %v", ProcessedAtHeader, err)
+	}
+	if processedAt.Before(before) || processedAt.After(time.Now().UTC()) {
+		t.Errorf("%s = %v, want the time the request was served", ProcessedAtHeader, processedAt)
+	}
+}
+
+func TestDefaultMiddlewareChain(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(DefaultMiddleware(Config{ServerID: "node-1", Timeout: time.Second, MaxHops: 2})...)
+	router.Get("/weather", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		hops       string
+		wantStatus int
+		wantServer string
+	}{
+		{"normalized path", "/Weather/", "", http.StatusOK, "node-1"},
+		// Recoverer answers outside the timeout writer, so the headers set
+		// further down the chain are dropped with the panicking response.
+		{"recovered panic", "/panic", "", http.StatusInternalServerError, ""},
+		{"hop limit", "/weather", "3", http.StatusLoopDetected, "node-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.hops != "" {
+				req.Header.Set(HopCountHeader, tt.hops)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if rec.Header().Get(DefaultRequestIDHeader) == "" {
+				t.Errorf("%s header missing", DefaultRequestIDHeader)
+			}
+			if got := rec.Header().Get(ServerIDHeader); got != tt.wantServer {
+				t.Errorf("%s = %q, want %q", ServerIDHeader, got, tt.wantServer)
+			}
+		})
+	}
+}
+
+func TestDefaultMiddlewareCompressionLevel(t *testing.T) {
+	var payload strings.Builder
+	payload.WriteString("[")
+	for i := range 2000 {
+		fmt.Fprintf(&payload, `{"cep":"%08d","temp_C":%d.%d,"city":"city-%d"},`, i*7919%100000000, i*31%45, i%10, i*i%997)
+	}
+	payload.WriteString("{}]")
+
+	sizes := map[int]int{}
+	for _, level := range []int{0, 1, 9} {
+		router := chi.NewRouter()
+		router.Use(DefaultMiddleware(Config{Timeout: time.Second, CompressionLevel: level})...)
+		router.Get("/weather", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(payload.String()))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		wantEncoding := "gzip"
+		if level == 0 {
+			wantEncoding = ""
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != wantEncoding {
+			t.Errorf("level %d: Content-Encoding = %q, want %q", level, got, wantEncoding)
+		}
+		sizes[level] = rec.Body.Len()
+	}
+
+	if sizes[0] != payload.Len() {
+		t.Errorf("level 0 body = %d bytes, want the uncompressed %d", sizes[0], payload.Len())
+	}
+	if sizes[9] >= sizes[1] {
+		t.Errorf("level 9 body = %d bytes, want smaller than level 1 (%d bytes)", sizes[9], sizes[1])
+	}
+}
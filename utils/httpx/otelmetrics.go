@@ -0,0 +1,67 @@
+package httpx
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type OTelMetrics struct {
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func NewOTelMetrics(service string) *OTelMetrics {
+	meter := otel.Meter(service)
+	m := &OTelMetrics{}
+
+	var err error
+	if m.requests, err = meter.Int64Counter("http.server.requests",
+		metric.WithDescription("HTTP requests handled, by route and final status code"),
+		metric.WithUnit("{request}")); err != nil {
+		slog.Error("failed to create http.server.requests counter", "error", err)
+	}
+	if m.duration, err = meter.Float64Histogram("http.server.latency",
+		metric.WithDescription("HTTP request latency in seconds"),
+		metric.WithUnit("s")); err != nil {
+		slog.Error("failed to create http.server.latency histogram", "error", err)
+	}
+	return m
+}
+
+func (m *OTelMetrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		attrs := metric.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.Int("http.status_code", status),
+		)
+		if m.requests != nil {
+			m.requests.Add(r.Context(), 1, attrs)
+		}
+		if m.duration != nil {
+			m.duration.Record(r.Context(), time.Since(start).Seconds(), attrs)
+		}
+	})
+}
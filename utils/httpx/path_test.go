@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/service-a", "/service-a"},
+		{"/Service-A", "/service-a"},
+		{"//service-a//batch", "/service-a/batch"},
+		{"/service-a/", "/service-a"},
+		{"/WEATHER//History/", "/weather/history"},
+		{"/", "/"},
+		{"//", "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var got string
+			handler := NormalizePath(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Path
+			}))
+			req := httptest.NewRequest(http.MethodGet, "http://service.test"+tt.path, nil)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tt.want {
+				t.Errorf("path %q normalized to %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
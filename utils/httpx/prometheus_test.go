@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestHTTPMetricsRecordsTraceExemplar(t *testing.T) {
+	metrics := NewHTTPMetrics("test-service")
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+
+	handler := metrics.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+	ctx, span := tracer.Start(req.Context(), "request")
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+	span.End()
+
+	scrape := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	scrape.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, scrape)
+
+	body, _ := io.ReadAll(rec.Body)
+	want := `trace_id="` + span.SpanContext().TraceID().String() + `"`
+	if !strings.Contains(string(body), want) {
+		t.Errorf("OpenMetrics scrape does not contain exemplar %s", want)
+	}
+}
@@ -0,0 +1,143 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultReadinessCheckTimeout = 2 * time.Second
+	DefaultReadinessCheckTTL     = 15 * time.Second
+)
+
+type ReadinessCheck func(ctx context.Context) error
+
+type checkResult struct {
+	err       error
+	checkedAt time.Time
+}
+
+type Readiness struct {
+	CheckTimeout time.Duration
+	CheckTTL     time.Duration
+
+	startedAt time.Time
+	grace     time.Duration
+	ready     atomic.Bool
+	draining  atomic.Bool
+	now       func() time.Time
+
+	mu      sync.Mutex
+	checks  map[string]ReadinessCheck
+	results map[string]checkResult
+}
+
+func NewReadiness(grace time.Duration) *Readiness {
+	return &Readiness{
+		CheckTimeout: DefaultReadinessCheckTimeout,
+		CheckTTL:     DefaultReadinessCheckTTL,
+		startedAt:    time.Now(),
+		grace:        grace,
+		now:          time.Now,
+		checks:       map[string]ReadinessCheck{},
+		results:      map[string]checkResult{},
+	}
+}
+
+func (rd *Readiness) MarkReady() {
+	if rd != nil {
+		rd.ready.Store(true)
+	}
+}
+
+func (rd *Readiness) MarkShuttingDown() {
+	if rd != nil {
+		rd.draining.Store(true)
+	}
+}
+
+func (rd *Readiness) ShuttingDown() bool {
+	return rd != nil && rd.draining.Load()
+}
+
+func (rd *Readiness) Ready() bool {
+	if rd == nil || rd.ready.Load() {
+		return true
+	}
+	if rd.now().Sub(rd.startedAt) >= rd.grace {
+		rd.ready.Store(true)
+		return true
+	}
+	return false
+}
+
+func (rd *Readiness) AddCheck(name string, check ReadinessCheck) {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	rd.checks[name] = check
+}
+
+func (rd *Readiness) failingChecks(ctx context.Context) map[string]string {
+	failing := map[string]string{}
+	if rd == nil {
+		return failing
+	}
+
+	rd.mu.Lock()
+	names := make([]string, 0, len(rd.checks))
+	for name := range rd.checks {
+		names = append(names, name)
+	}
+	rd.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := rd.runCheck(ctx, name); err != nil {
+			failing[name] = err.Error()
+		}
+	}
+	return failing
+}
+
+func (rd *Readiness) runCheck(ctx context.Context, name string) error {
+	rd.mu.Lock()
+	check := rd.checks[name]
+	if result, ok := rd.results[name]; ok && rd.now().Sub(result.checkedAt) < rd.CheckTTL {
+		rd.mu.Unlock()
+		return result.err
+	}
+	rd.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, rd.CheckTimeout)
+	defer cancel()
+	err := check(ctx)
+
+	rd.mu.Lock()
+	rd.results[name] = checkResult{err: err, checkedAt: rd.now()}
+	rd.mu.Unlock()
+	return err
+}
+
+func (rd *Readiness) Handler(w http.ResponseWriter, r *http.Request) {
+	if rd.ShuttingDown() {
+		WriteStatus(w, map[string]string{"status": "shutting_down"}, StatusDown)
+		return
+	}
+	if !rd.Ready() {
+		WriteStatus(w, map[string]string{"status": "starting"}, StatusDown)
+		return
+	}
+	if failing := rd.failingChecks(r.Context()); len(failing) > 0 {
+		WriteStatus(w, map[string]any{"status": StatusDown, "failing": failing}, StatusDown)
+		return
+	}
+	WriteStatus(w, map[string]string{"status": StatusOK}, StatusOK)
+}
+
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	WriteStatus(w, map[string]string{"status": StatusOK}, StatusOK)
+}
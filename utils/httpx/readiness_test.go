@@ -0,0 +1,48 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadinessGracePeriod(t *testing.T) {
+	probe := func(rd *Readiness) int {
+		rec := httptest.NewRecorder()
+		rd.Handler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	t.Run("flips after the grace period", func(t *testing.T) {
+		rd := NewReadiness(30 * time.Second)
+		now := rd.startedAt
+		rd.now = func() time.Time { return now }
+
+		if code := probe(rd); code != http.StatusServiceUnavailable {
+			t.Fatalf("status at startup = %d, want 503", code)
+		}
+		now = now.Add(29 * time.Second)
+		if code := probe(rd); code != http.StatusServiceUnavailable {
+			t.Fatalf("status before grace = %d, want 503", code)
+		}
+		now = now.Add(time.Second)
+		if code := probe(rd); code != http.StatusOK {
+			t.Fatalf("status after grace = %d, want 200", code)
+		}
+	})
+
+	t.Run("first successful upstream call ends the grace early", func(t *testing.T) {
+		rd := NewReadiness(time.Hour)
+		now := rd.startedAt
+		rd.now = func() time.Time { return now }
+
+		if code := probe(rd); code != http.StatusServiceUnavailable {
+			t.Fatalf("status at startup = %d, want 503", code)
+		}
+		rd.MarkReady()
+		if code := probe(rd); code != http.StatusOK {
+			t.Fatalf("status after MarkReady = %d, want 200", code)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package httpx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const DefaultRequestIDHeader = "X-Request-Id"
+
+type requestIDHeaderKey struct{}
+
+var requestIDPrefix = newRequestIDPrefix()
+
+func newRequestIDPrefix() string {
+	hostname, err := os.Hostname()
+	if hostname == "" || err != nil {
+		hostname = "localhost"
+	}
+	var buf [5]byte
+	rand.Read(buf[:])
+	return hostname + "/" + hex.EncodeToString(buf[:])
+}
+
+func RequestID(header string) func(http.Handler) http.Handler {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(header)
+			if id == "" {
+				id = fmt.Sprintf("%s-%06d", requestIDPrefix, middleware.NextRequestID())
+			}
+
+			ctx := context.WithValue(r.Context(), middleware.RequestIDKey, id)
+			ctx = context.WithValue(ctx, requestIDHeaderKey{}, header)
+			w.Header().Set(header, id)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("request.id", id))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func SetRequestID(ctx context.Context, req *http.Request) {
+	id := middleware.GetReqID(ctx)
+	if id == "" {
+		return
+	}
+	header, _ := ctx.Value(requestIDHeaderKey{}).(string)
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	req.Header.Set(header, id)
+}
@@ -0,0 +1,56 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequestIDHeadersAreIndependent(t *testing.T) {
+	var forwarded http.Header
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		out := httptest.NewRequest(http.MethodGet, "http://upstream.test/", nil)
+		SetRequestID(r.Context(), out)
+		forwarded = out.Header
+	}
+
+	correlation := RequestID("X-Correlation-Id")(http.HandlerFunc(handler))
+	standard := RequestID("")(http.HandlerFunc(handler))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		header  string
+	}{
+		{"custom header", correlation, "X-Correlation-Id"},
+		{"default header", standard, DefaultRequestIDHeader},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(tt.header, "abc-123")
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get(tt.header); got != "abc-123" {
+				t.Errorf("echoed %s = %q, want abc-123", tt.header, got)
+			}
+			if got := forwarded.Get(tt.header); got != "abc-123" {
+				t.Errorf("forwarded %s = %q, want abc-123", tt.header, got)
+			}
+		})
+	}
+
+	if middleware.RequestIDHeader != DefaultRequestIDHeader {
+		t.Errorf("chi RequestIDHeader changed to %q", middleware.RequestIDHeader)
+	}
+
+	rec := httptest.NewRecorder()
+	correlation.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Header().Get("X-Correlation-Id") == "" {
+		t.Error("expected a generated request id when none is sent")
+	}
+}
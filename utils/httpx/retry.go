@@ -0,0 +1,194 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultRetryMaxRetries = 3
+	DefaultRetryMaxDelay   = 5 * time.Second
+	DefaultRetryBaseDelay  = 100 * time.Millisecond
+)
+
+const (
+	RetryErrorsNone    = "none"
+	RetryErrorsConnect = "connect"
+)
+
+type RetryPolicy struct {
+	MaxRetries  int
+	MaxDelay    time.Duration
+	BaseDelay   time.Duration
+	RetryErrors string
+	Jitter      string
+	Rand        *rand.Rand
+}
+
+func RetryAfter(resp *http.Response, maxDelay time.Duration) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		delay = time.Until(at)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay, true
+}
+
+type retryLimitsKey struct{}
+
+type retryLimits struct {
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+func WithRetryLimits(ctx context.Context, maxRetries int, baseDelay time.Duration) context.Context {
+	return context.WithValue(ctx, retryLimitsKey{}, retryLimits{maxRetries: maxRetries, baseDelay: baseDelay})
+}
+
+func (t *retryTransport) limits(ctx context.Context) (int, time.Duration) {
+	if limits, ok := ctx.Value(retryLimitsKey{}).(retryLimits); ok {
+		return limits.maxRetries, limits.baseDelay
+	}
+	return t.policy.MaxRetries, t.policy.BaseDelay
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+func hasBody(req *http.Request) bool {
+	return req.Body != nil && req.Body != http.NoBody
+}
+
+type retryTransport struct {
+	next    http.RoundTripper
+	policy  RetryPolicy
+	backoff *Backoff
+}
+
+func NewRetryTransport(next http.RoundTripper, policy RetryPolicy) http.RoundTripper {
+	return &retryTransport{
+		next:    next,
+		policy:  policy,
+		backoff: NewBackoff(policy.Jitter, policy.BaseDelay, policy.MaxDelay, policy.Rand),
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxRetries, baseDelay := t.limits(req.Context())
+	var delay time.Duration
+	for attempt := 1; ; attempt++ {
+		resp, wroteRequest, err := t.attempt(req, attempt)
+		if hasBody(req) {
+			return resp, err
+		}
+
+		switch {
+		case err != nil:
+			if !t.shouldRetryError(wroteRequest) {
+				return nil, err
+			}
+			if attempt > maxRetries {
+				RecordRetryExhausted(req.Context(), req.URL.Host, attempt, err)
+				return nil, err
+			}
+			delay = t.backoff.DelayFrom(baseDelay, attempt, delay)
+		case isRetryableStatus(resp.StatusCode):
+			if attempt > maxRetries {
+				RecordRetryExhausted(req.Context(), req.URL.Host, attempt, fmt.Errorf("upstream returned status %d", resp.StatusCode))
+				return resp, nil
+			}
+			if retryAfter, ok := RetryAfter(resp, t.policy.MaxDelay); ok {
+				delay = retryAfter
+			} else if resp.StatusCode == http.StatusTooManyRequests {
+				return resp, nil
+			} else {
+				delay = t.backoff.DelayFrom(baseDelay, attempt, delay)
+			}
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		RecordRetry(req.Context())
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (t *retryTransport) attempt(req *http.Request, attempt int) (*http.Response, bool, error) {
+	ctx, span := otel.Tracer("httpx").Start(req.Context(), "http-attempt", trace.WithAttributes(
+		attribute.Int("retry.attempt", attempt),
+		attribute.String("retry.upstream", req.URL.Host),
+	))
+	defer span.End()
+
+	var wroteRequest atomic.Bool
+	attemptReq := req.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) { wroteRequest.Store(true) },
+	}))
+
+	resp, err := t.next.RoundTrip(attemptReq)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "attempt failed")
+		return nil, wroteRequest.Load(), err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, "upstream returned server error")
+	}
+	return resp, true, nil
+}
+
+func (t *retryTransport) shouldRetryError(wroteRequest bool) bool {
+	switch t.policy.RetryErrors {
+	case RetryErrorsNone:
+		return false
+	default:
+		return !wroteRequest
+	}
+}
+
+func RecordRetryExhausted(ctx context.Context, upstream string, attempts int, err error) {
+	trace.SpanFromContext(ctx).AddEvent("retry.exhausted", trace.WithAttributes(
+		attribute.String("retry.upstream", upstream),
+		attribute.Int("retry.attempts", attempts),
+		attribute.String("retry.error", redactError(err)),
+	))
+}
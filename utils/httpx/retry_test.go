@@ -0,0 +1,272 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type stubRoundTripper struct {
+	calls   int
+	respond func(req *http.Request) (*http.Response, error)
+}
+
+func (s *stubRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	return s.respond(req)
+}
+
+func statusResponse(code int) (*http.Response, error) {
+	return &http.Response{StatusCode: code, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestRetryTransport(t *testing.T) {
+	errRefused := errors.New("connect: connection refused")
+
+	tests := []struct {
+		name        string
+		retryErrors string
+		respond     func(req *http.Request) (*http.Response, error)
+		wantCalls   int
+	}{
+		{
+			name:      "connection error retried by default",
+			respond:   func(*http.Request) (*http.Response, error) { return nil, errRefused },
+			wantCalls: DefaultRetryMaxRetries + 1,
+		},
+		{
+			name:        "connection error not retried when disabled",
+			retryErrors: RetryErrorsNone,
+			respond:     func(*http.Request) (*http.Response, error) { return nil, errRefused },
+			wantCalls:   1,
+		},
+		{
+			name: "error after request was written is not retried",
+			respond: func(req *http.Request) (*http.Response, error) {
+				httptrace.ContextClientTrace(req.Context()).WroteRequest(httptrace.WroteRequestInfo{})
+				return nil, context.DeadlineExceeded
+			},
+			wantCalls: 1,
+		},
+		{
+			name:      "server error retried",
+			respond:   func(*http.Request) (*http.Response, error) { return statusResponse(http.StatusBadGateway) },
+			wantCalls: DefaultRetryMaxRetries + 1,
+		},
+		{
+			name:      "client error not retried",
+			respond:   func(*http.Request) (*http.Response, error) { return statusResponse(http.StatusNotFound) },
+			wantCalls: 1,
+		},
+		{
+			name:      "success not retried",
+			respond:   func(*http.Request) (*http.Response, error) { return statusResponse(http.StatusOK) },
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubRoundTripper{respond: tt.respond}
+			transport := NewRetryTransport(stub, RetryPolicy{MaxRetries: DefaultRetryMaxRetries, RetryErrors: tt.retryErrors})
+
+			req, _ := http.NewRequest(http.MethodGet, "http://upstream.test/", nil)
+			if resp, err := transport.RoundTrip(req); err == nil {
+				resp.Body.Close()
+			}
+			if stub.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", stub.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryTransportContextLimits(t *testing.T) {
+	stub := &stubRoundTripper{respond: func(*http.Request) (*http.Response, error) { return statusResponse(http.StatusServiceUnavailable) }}
+	transport := NewRetryTransport(stub, RetryPolicy{MaxRetries: DefaultRetryMaxRetries})
+
+	req, _ := http.NewRequestWithContext(WithRetryLimits(context.Background(), 1, 0), http.MethodGet, "http://upstream.test/", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+	resp.Body.Close()
+	if stub.calls != 2 {
+		t.Errorf("calls = %d, want 2", stub.calls)
+	}
+}
+
+func TestRetryTransportRecordsExhaustion(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "call")
+
+	leaky := &url.Error{Op: "Get", URL: "https://api.weatherapi.test/v1/current.json?key=secret-key&q=Sao+Paulo", Err: io.ErrUnexpectedEOF}
+	stub := &stubRoundTripper{respond: func(*http.Request) (*http.Response, error) { return nil, leaky }}
+	transport := NewRetryTransport(stub, RetryPolicy{MaxRetries: 2})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.weatherapi.test/v1/current.json", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Fatal("RoundTrip() = nil error, want the upstream error")
+	}
+	span.End()
+
+	var event *sdktrace.Event
+	for _, s := range recorder.Ended() {
+		for _, e := range s.Events() {
+			if e.Name == "retry.exhausted" {
+				event = &e
+			}
+		}
+	}
+	if event == nil {
+		t.Fatal("no retry.exhausted event recorded")
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range event.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	if got := attrs["retry.upstream"].AsString(); got != "api.weatherapi.test" {
+		t.Errorf("retry.upstream = %q, want api.weatherapi.test", got)
+	}
+	if got := attrs["retry.attempts"].AsInt64(); got != 3 {
+		t.Errorf("retry.attempts = %d, want 3", got)
+	}
+	retryErr := attrs["retry.error"].AsString()
+	if strings.Contains(retryErr, "secret-key") {
+		t.Errorf("retry.error leaks the api key: %q", retryErr)
+	}
+	if !strings.Contains(retryErr, "https://api.weatherapi.test/v1/current.json") {
+		t.Errorf("retry.error = %q, want the redacted URL", retryErr)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		maxDelay time.Duration
+		wantMin  time.Duration
+		wantMax  time.Duration
+		wantOK   bool
+	}{
+		{name: "seconds", header: "2", wantMin: 2 * time.Second, wantMax: 2 * time.Second, wantOK: true},
+		{name: "seconds capped", header: "120", maxDelay: 5 * time.Second, wantMin: 5 * time.Second, wantMax: 5 * time.Second, wantOK: true},
+		{name: "http date", header: time.Now().Add(3 * time.Second).UTC().Format(http.TimeFormat), wantMin: time.Second, wantMax: 3 * time.Second, wantOK: true},
+		{name: "http date in the past", header: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), wantOK: true},
+		{name: "missing", header: ""},
+		{name: "malformed", header: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := statusResponse(http.StatusTooManyRequests)
+			if tt.header != "" {
+				resp.Header.Set("Retry-After", tt.header)
+			}
+
+			got, ok := RetryAfter(resp, tt.maxDelay)
+			if ok != tt.wantOK || got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("RetryAfter(%q) = %s, %v; want between %s and %s, %v", tt.header, got, ok, tt.wantMin, tt.wantMax, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryTransportHonorsRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		wantCalls  int
+		wantStatus int
+	}{
+		{"429 with Retry-After is retried", "0", 2, http.StatusOK},
+		{"429 without Retry-After is returned", "", 1, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubRoundTripper{}
+			stub.respond = func(*http.Request) (*http.Response, error) {
+				if stub.calls > 1 {
+					return statusResponse(http.StatusOK)
+				}
+				resp, err := statusResponse(http.StatusTooManyRequests)
+				if tt.retryAfter != "" {
+					resp.Header.Set("Retry-After", tt.retryAfter)
+				}
+				return resp, err
+			}
+			transport := NewRetryTransport(stub, RetryPolicy{MaxRetries: DefaultRetryMaxRetries})
+
+			req, _ := http.NewRequest(http.MethodGet, "http://upstream.test/", nil)
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip: %v", err)
+			}
+			resp.Body.Close()
+			if stub.calls != tt.wantCalls || resp.StatusCode != tt.wantStatus {
+				t.Errorf("calls = %d, status = %d; want %d, %d", stub.calls, resp.StatusCode, tt.wantCalls, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRetryTransportConnectPhaseOnly(t *testing.T) {
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	refusedURL := closed.URL
+	closed.Close()
+
+	var served atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantCalls  int
+		wantServed int32
+	}{
+		{"connection refused is retried", refusedURL, DefaultRetryMaxRetries + 1, 0},
+		{"slow response is not retried", slow.URL, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			served.Store(0)
+			next := http.DefaultTransport.(*http.Transport).Clone()
+			defer next.CloseIdleConnections()
+			stub := &stubRoundTripper{respond: next.RoundTrip}
+			transport := NewRetryTransport(stub, RetryPolicy{MaxRetries: DefaultRetryMaxRetries, BaseDelay: time.Millisecond})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, tt.url, nil)
+			if resp, err := transport.RoundTrip(req); err == nil {
+				resp.Body.Close()
+				t.Fatal("RoundTrip() = nil error, want a failure")
+			}
+			if stub.calls != tt.wantCalls {
+				t.Errorf("attempts = %d, want %d", stub.calls, tt.wantCalls)
+			}
+			if got := served.Load(); got != tt.wantServed {
+				t.Errorf("requests reaching the server = %d, want %d", got, tt.wantServed)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package httpx
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+func Sign(secret, method, path, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type signingTransport struct {
+	next   http.RoundTripper
+	secret string
+}
+
+func NewSigningTransport(next http.RoundTripper, secret string) http.RoundTripper {
+	if secret == "" {
+		return next
+	}
+	return &signingTransport{next: next, secret: secret}
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	signed := req.Clone(req.Context())
+	signed.Header.Set(SignatureTimestampHeader, timestamp)
+	signed.Header.Set(SignatureHeader, Sign(t.secret, req.Method, req.URL.EscapedPath(), timestamp))
+	return t.next.RoundTrip(signed)
+}
@@ -0,0 +1,44 @@
+package httpx
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	const want = "873304e664146c670c7ce7095bd697a402c5b68de5950d99a173ab044b470697"
+	if got := Sign("shared-secret", http.MethodGet, "/weather", "1700000000"); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestSigningTransport(t *testing.T) {
+	var signed *http.Request
+	stub := &stubRoundTripper{respond: func(req *http.Request) (*http.Response, error) {
+		signed = req
+		return statusResponse(http.StatusOK)
+	}}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://service-b.test/weather?cep=01001000", nil)
+	if _, err := NewSigningTransport(stub, "shared-secret").RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	timestamp := signed.Header.Get(SignatureTimestampHeader)
+	if timestamp == "" {
+		t.Fatal("no signature timestamp header")
+	}
+	if got, want := signed.Header.Get(SignatureHeader), Sign("shared-secret", http.MethodGet, "/weather", timestamp); got != want {
+		t.Errorf("%s = %s, want %s", SignatureHeader, got, want)
+	}
+	if req.Header.Get(SignatureHeader) != "" {
+		t.Error("signing mutated the caller's request")
+	}
+}
+
+func TestSigningTransportDisabledWithoutSecret(t *testing.T) {
+	stub := &stubRoundTripper{}
+	if got := NewSigningTransport(stub, ""); got != http.RoundTripper(stub) {
+		t.Errorf("NewSigningTransport without a secret = %T, want the next transport", got)
+	}
+}
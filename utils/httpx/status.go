@@ -0,0 +1,58 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+type ServiceStatus struct {
+	Service string         `json:"service"`
+	Status  string         `json:"status"`
+	Version string         `json:"version,omitempty"`
+	Uptime  string         `json:"uptime,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func Version() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+func NewServiceStatus(service string, startedAt time.Time) ServiceStatus {
+	return ServiceStatus{
+		Service: service,
+		Status:  StatusOK,
+		Version: Version(),
+		Uptime:  time.Since(startedAt).Round(time.Second).String(),
+	}
+}
+
+func WriteStatus(w http.ResponseWriter, data any, status string) {
+	code := http.StatusOK
+	if status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, data, code)
+}
+
+func writeJSON(w http.ResponseWriter, data any, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,107 @@
+package httpx
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type RequestSummary struct {
+	upstreamCalls atomic.Int64
+	retries       atomic.Int64
+	cacheHits     atomic.Int64
+	cacheMisses   atomic.Int64
+}
+
+type summaryKey struct{}
+
+func summaryFromContext(ctx context.Context) *RequestSummary {
+	summary, _ := ctx.Value(summaryKey{}).(*RequestSummary)
+	return summary
+}
+
+func RecordUpstreamCall(ctx context.Context) {
+	if summary := summaryFromContext(ctx); summary != nil {
+		summary.upstreamCalls.Add(1)
+	}
+}
+
+func RecordRetry(ctx context.Context) {
+	if summary := summaryFromContext(ctx); summary != nil {
+		summary.retries.Add(1)
+	}
+}
+
+func RecordCache(ctx context.Context, hit bool) {
+	summary := summaryFromContext(ctx)
+	if summary == nil {
+		return
+	}
+	if hit {
+		summary.cacheHits.Add(1)
+	} else {
+		summary.cacheMisses.Add(1)
+	}
+}
+
+const CacheHeader = "X-Cache"
+
+func SetCacheHeader(ctx context.Context, w http.ResponseWriter) {
+	value := "MISS"
+	if summary := summaryFromContext(ctx); summary != nil && summary.cacheStatus() == "hit" {
+		value = "HIT"
+	}
+	w.Header().Set(CacheHeader, value)
+}
+
+func (s *RequestSummary) cacheStatus() string {
+	switch {
+	case s.cacheHits.Load() == 0 && s.cacheMisses.Load() == 0:
+		return "none"
+	case s.cacheMisses.Load() == 0:
+		return "hit"
+	case s.cacheHits.Load() == 0:
+		return "miss"
+	default:
+		return "partial"
+	}
+}
+
+func Summary(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		summary := &RequestSummary{}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), summaryKey{}, summary)))
+
+		slog.InfoContext(r.Context(), "request_summary",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", ww.Status()),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote", r.RemoteAddr),
+			slog.String("cache", summary.cacheStatus()),
+			slog.Int64("upstream_calls", summary.upstreamCalls.Load()),
+			slog.Int64("retries", summary.retries.Load()),
+		)
+	})
+}
+
+type countingTransport struct {
+	next http.RoundTripper
+}
+
+func CountingTransport(next http.RoundTripper) http.RoundTripper {
+	return &countingTransport{next: next}
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	RecordUpstreamCall(req.Context())
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSummaryLogsStructuredAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	handler := Summary(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RecordUpstreamCall(r.Context())
+		RecordRetry(r.Context())
+		RecordCache(r.Context(), true)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather", nil))
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("summary is not a single JSON record: %v\n%s", err, buf.String())
+	}
+
+	want := map[string]any{
+		"msg":            "request_summary",
+		"method":         http.MethodGet,
+		"path":           "/weather",
+		"status":         float64(http.StatusAccepted),
+		"cache":          "hit",
+		"upstream_calls": float64(1),
+		"retries":        float64(1),
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("%s = %v, want %v", key, record[key], value)
+		}
+	}
+}
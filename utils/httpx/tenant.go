@@ -0,0 +1,57 @@
+package httpx
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultTenantHeader = "X-Tenant-Id"
+	TenantBaggageKey    = "tenant.id"
+)
+
+func Tenant(header string) func(http.Handler) http.Handler {
+	if header == "" {
+		header = DefaultTenantHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if id := r.Header.Get(header); id != "" {
+				withTenant, err := WithTenant(ctx, id)
+				if err != nil {
+					slog.WarnContext(ctx, "ignoring invalid tenant header", "header", header, "value", id, "error", err)
+				} else {
+					ctx = withTenant
+				}
+			}
+
+			if id := TenantFromContext(ctx); id != "" {
+				trace.SpanFromContext(ctx).SetAttributes(attribute.String(TenantBaggageKey, id))
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func WithTenant(ctx context.Context, id string) (context.Context, error) {
+	member, err := baggage.NewMemberRaw(TenantBaggageKey, id)
+	if err != nil {
+		return ctx, err
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx, err
+	}
+	return baggage.ContextWithBaggage(ctx, bag), nil
+}
+
+func TenantFromContext(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(TenantBaggageKey).Value()
+}
@@ -0,0 +1,105 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const TimeoutMessage = "request timed out"
+
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+	finished    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) copyHeaders() {
+	dst := tw.w.Header()
+	for key := range dst {
+		if _, ok := tw.h[key]; !ok {
+			delete(dst, key)
+		}
+	}
+	for key, values := range tw.h {
+		dst[key] = values
+	}
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.copyHeaders()
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.copyHeaders()
+	}
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.finished || tw.wroteHeader {
+		return
+	}
+	tw.timedOut = true
+	writeJSON(tw.w, map[string]string{"code": "TIMEOUT", "message": TimeoutMessage}, http.StatusGatewayTimeout)
+}
+
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.finished = true
+}
+
+func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, h: w.Header().Clone()}
+			timer := time.AfterFunc(timeout, tw.timeout)
+			defer func() {
+				timer.Stop()
+				tw.finish()
+			}()
+
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		delay      time.Duration
+		wantStatus int
+		wantCode   string
+	}{
+		{"fast handler", 0, http.StatusTeapot, ""},
+		{"slow handler", 200 * time.Millisecond, http.StatusGatewayTimeout, "TIMEOUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Timeout(50 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.delay > 0 {
+					<-r.Context().Done()
+					time.Sleep(tt.delay - 50*time.Millisecond)
+				}
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte("late"))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var body map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decode %q: %v", rec.Body, err)
+			}
+			if body["code"] != tt.wantCode || body["message"] != TimeoutMessage {
+				t.Errorf("body = %v, want code %s and message %q", body, tt.wantCode, TimeoutMessage)
+			}
+		})
+	}
+}
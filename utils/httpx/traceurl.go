@@ -0,0 +1,21 @@
+package httpx
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TraceIDPlaceholder = "{trace_id}"
+
+func TraceURL(ctx context.Context, template string) string {
+	if template == "" {
+		return ""
+	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return strings.ReplaceAll(template, TraceIDPlaceholder, spanContext.TraceID().String())
+}
@@ -0,0 +1,36 @@
+package httpx
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceURL(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		template string
+		want     string
+	}{
+		{"templated", traced, "http://jaeger:16686/trace/{trace_id}", "http://jaeger:16686/trace/4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"repeated placeholder", traced, "/{trace_id}?q={trace_id}", "/4bf92f3577b34da6a3ce929d0e0e4736?q=4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"disabled", traced, "", ""},
+		{"no active trace", context.Background(), "http://jaeger:16686/trace/{trace_id}", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TraceURL(tt.ctx, tt.template); got != tt.want {
+				t.Errorf("TraceURL(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultConnectTimeout = 2 * time.Second
+	DefaultRequestTimeout = 5 * time.Second
+)
+
+const (
+	DialNetworkTCP  = "tcp"
+	DialNetworkTCP4 = "tcp4"
+	DialNetworkTCP6 = "tcp6"
+)
+
+func NewTransport(network string, connectTimeout time.Duration, allowPrivate bool) (*http.Transport, error) {
+	switch network {
+	case "":
+		network = DialNetworkTCP
+	case DialNetworkTCP, DialNetworkTCP4, DialNetworkTCP6:
+	default:
+		return nil, fmt.Errorf("unsupported dial network %q", network)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if !allowPrivate {
+		dialer.Control = denyNonPublic
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport, nil
+}
+
+// denyNonPublic runs after DNS resolution, right before connect, so a host
+// that later resolves to an internal address is refused on that dial.
+func denyNonPublic(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isNonPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, host)
+	}
+	return nil
+}
+
+func LimitConnsPerHost(transport *http.Transport, maxConns int) {
+	if maxConns <= 0 {
+		return
+	}
+	transport.MaxConnsPerHost = maxConns
+	if transport.MaxIdleConnsPerHost < maxConns {
+		transport.MaxIdleConnsPerHost = maxConns
+	}
+}
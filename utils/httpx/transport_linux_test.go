@@ -0,0 +1,88 @@
+package httpx
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// saturatedListener returns the address of a listener whose accept queue is
+// already full, so further connects hang in the SYN stage until they time out.
+func saturatedListener(t *testing.T) string {
+	t.Helper()
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatalf("socket: %v", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	if err := syscall.Listen(fd, 0); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	listener, err := net.FileListener(os.NewFile(uintptr(fd), "saturated"))
+	if err != nil {
+		t.Fatalf("FileListener: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	filler, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("fill accept queue: %v", err)
+	}
+	t.Cleanup(func() { filler.Close() })
+	return listener.Addr().String()
+}
+
+func TestNewTransportConnectTimeoutVersusSlowBody(t *testing.T) {
+	const connectTimeout = 100 * time.Millisecond
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(3 * connectTimeout)
+		w.Write([]byte("done"))
+	}))
+	defer slow.Close()
+
+	transport, err := NewTransport("", connectTimeout, true)
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+
+	t.Run("connect phase is bounded", func(t *testing.T) {
+		start := time.Now()
+		resp, err := client.Get("http://" + saturatedListener(t))
+		if err == nil {
+			resp.Body.Close()
+			t.Fatal("Get() = nil error, want a connect timeout")
+		}
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("Get() error = %v, want a timeout", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*connectTimeout {
+			t.Errorf("connect gave up after %s, want about %s", elapsed, connectTimeout)
+		}
+	})
+
+	t.Run("slow body is not cut off", func(t *testing.T) {
+		resp, err := client.Get(slow.URL)
+		if err != nil {
+			t.Fatalf("Get() = %v, want the slow body", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil || string(body) != "done" {
+			t.Errorf("body = %q, %v, want done", body, err)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package httpx
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewTransportDeniesNonPublicAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		allowPrivate bool
+		wantErr      error
+	}{
+		{"denied by default", false, ErrNonPublicAddress},
+		{"allowed when opted in", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := NewTransport("", time.Second, tt.allowPrivate)
+			if err != nil {
+				t.Fatalf("NewTransport: %v", err)
+			}
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Get(%s) error = %v, want %v", server.URL, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTransportDialsConfiguredNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	target := "http://localhost:" + port
+
+	tests := []struct {
+		network string
+		wantErr bool
+	}{
+		{"", false},
+		{DialNetworkTCP, false},
+		{DialNetworkTCP4, false},
+		{DialNetworkTCP6, true},
+	}
+	for _, tt := range tests {
+		t.Run("network "+tt.network, func(t *testing.T) {
+			transport, err := NewTransport(tt.network, time.Second, true)
+			if err != nil {
+				t.Fatalf("NewTransport: %v", err)
+			}
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get(target)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get(%s) over %q error = %v, want error %v", target, tt.network, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := NewTransport("udp", time.Second, true); err == nil {
+		t.Error("NewTransport(udp) = nil error, want unsupported network")
+	}
+}
+
+func TestLimitConnsPerHost(t *testing.T) {
+	var opened atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transport, err := NewTransport("", time.Second, true)
+	if err != nil {
+		t.Fatalf("NewTransport: %v", err)
+	}
+	defer transport.CloseIdleConnections()
+	LimitConnsPerHost(transport, 2)
+	if transport.MaxConnsPerHost != 2 || transport.MaxIdleConnsPerHost < 2 {
+		t.Fatalf("MaxConnsPerHost = %d, MaxIdleConnsPerHost = %d, want 2 and at least 2",
+			transport.MaxConnsPerHost, transport.MaxIdleConnsPerHost)
+	}
+
+	client := &http.Client{Transport: transport}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("Get: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := opened.Load(); got > 2 {
+		t.Errorf("opened %d connections, want at most 2", got)
+	}
+
+	unlimited, _ := NewTransport("", time.Second, true)
+	LimitConnsPerHost(unlimited, 0)
+	if unlimited.MaxConnsPerHost != 0 {
+		t.Errorf("LimitConnsPerHost(0) set MaxConnsPerHost = %d, want unlimited", unlimited.MaxConnsPerHost)
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+const DefaultMetricsExportInterval = 30 * time.Second
+
+const (
+	MetricsExporterOTLP    = "otlp"
+	MetricsExporterConsole = "console"
+	MetricsExporterNone    = "none"
+)
+
+func InitMeterProvider(ctx context.Context, serviceName string, exportInterval time.Duration) (*sdkmetric.MeterProvider, func(context.Context) error, error) {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+		),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	opts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+
+	reader, err := newMetricReader(ctx, os.Getenv("OTEL_METRICS_EXPORTER"), exportInterval)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reader != nil {
+		opts = append(opts, sdkmetric.WithReader(reader))
+	}
+
+	mp := sdkmetric.NewMeterProvider(opts...)
+	otel.SetMeterProvider(mp)
+
+	return mp, mp.Shutdown, nil
+}
+
+func newMetricReader(ctx context.Context, metricsExporter string, exportInterval time.Duration) (sdkmetric.Reader, error) {
+	switch metricsExporter {
+	case "", MetricsExporterOTLP:
+		exporter, err := newOTLPMetricExporter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(exportInterval)), nil
+	case MetricsExporterConsole:
+		exporter, err := stdoutmetric.New(stdoutmetric.WithWriter(os.Stdout), stdoutmetric.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create console metric exporter: %w", err)
+		}
+		return sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(exportInterval)), nil
+	case MetricsExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported metrics exporter %q", metricsExporter)
+	}
+}
+
+func newOTLPMetricExporter(ctx context.Context) (sdkmetric.Exporter, error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	headers, err := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+
+	switch protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol {
+	case "", OTLPProtocolGRPC:
+		if endpoint == "" {
+			endpoint = DefaultOTLPGRPCEndpoint
+		}
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithHeaders(headers)}
+		if strings.Contains(endpoint, "://") {
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(endpoint))
+		} else {
+			opts = append(opts, otlpmetricgrpc.WithEndpoint(endpoint), otlpmetricgrpc.WithInsecure())
+		}
+
+		exporter, err := otlpmetricgrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP gRPC metric exporter: %w", err)
+		}
+		return exporter, nil
+	case OTLPProtocolHTTPProtobuf:
+		if endpoint == "" {
+			endpoint = DefaultOTLPHTTPEndpoint
+		}
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(headers)}
+		if strings.Contains(endpoint, "://") {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/metrics"))
+		} else {
+			opts = append(opts, otlpmetrichttp.WithEndpoint(endpoint), otlpmetrichttp.WithInsecure())
+		}
+
+		exporter, err := otlpmetrichttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP HTTP metric exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", protocol)
+	}
+}
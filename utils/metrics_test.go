@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewMetricReader(t *testing.T) {
+	tests := []struct {
+		name       string
+		exporter   string
+		wantReader bool
+		wantErr    bool
+	}{
+		{"defaults to otlp", "", true, false},
+		{"otlp", MetricsExporterOTLP, true, false},
+		{"console", MetricsExporterConsole, true, false},
+		{"none", MetricsExporterNone, false, false},
+		{"unsupported", "zipkin", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := newMetricReader(context.Background(), tt.exporter, time.Minute)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newMetricReader(%q) error = %v, wantErr %v", tt.exporter, err, tt.wantErr)
+			}
+			if (reader != nil) != tt.wantReader {
+				t.Fatalf("newMetricReader(%q) reader = %v, wantReader %v", tt.exporter, reader, tt.wantReader)
+			}
+			if reader != nil {
+				reader.Shutdown(context.Background())
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+const DefaultRuntimeMetricsInterval = 15 * time.Second
+
+type runtimeGauges struct {
+	goroutines metric.Int64Gauge
+	heapAlloc  metric.Int64Gauge
+	gcPause    metric.Float64Gauge
+}
+
+func StartRuntimeMetrics(ctx context.Context, provider metric.MeterProvider, interval time.Duration) error {
+	meter := provider.Meter("runtime")
+
+	goroutines, err := meter.Int64Gauge("runtime.goroutines", metric.WithDescription("Number of live goroutines"))
+	if err != nil {
+		return fmt.Errorf("failed to create goroutines gauge: %w", err)
+	}
+	heapAlloc, err := meter.Int64Gauge("runtime.heap_alloc", metric.WithUnit("By"), metric.WithDescription("Bytes of allocated heap objects"))
+	if err != nil {
+		return fmt.Errorf("failed to create heap alloc gauge: %w", err)
+	}
+	gcPause, err := meter.Float64Gauge("runtime.gc_pause", metric.WithUnit("s"), metric.WithDescription("Duration of the most recent GC pause"))
+	if err != nil {
+		return fmt.Errorf("failed to create gc pause gauge: %w", err)
+	}
+
+	gauges := runtimeGauges{goroutines: goroutines, heapAlloc: heapAlloc, gcPause: gcPause}
+	gauges.record(ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				gauges.record(ctx)
+			}
+		}
+	}()
+	return nil
+}
+
+func (g runtimeGauges) record(ctx context.Context) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	g.goroutines.Record(ctx, int64(runtime.NumGoroutine()))
+	g.heapAlloc.Record(ctx, int64(stats.HeapAlloc))
+	g.gcPause.Record(ctx, time.Duration(stats.PauseNs[(stats.NumGC+255)%256]).Seconds())
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestStartRuntimeMetricsRecordsOnProvider(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := StartRuntimeMetrics(ctx, provider, time.Hour); err != nil {
+		t.Fatalf("StartRuntimeMetrics: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	got := map[string]bool{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			got[m.Name] = true
+		}
+	}
+	for _, want := range []string{"runtime.goroutines", "runtime.heap_alloc", "runtime.gc_pause"} {
+		if !got[want] {
+			t.Errorf("metric %q not recorded, got %v", want, got)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInitTracerProviderInstallsCompositePropagator(t *testing.T) {
+	t.Setenv("OTEL_TRACES_EXPORTER", TracesExporterNone)
+
+	_, shutdown, err := InitTracerProvider(context.Background(), "test-service")
+	if err != nil {
+		t.Fatalf("InitTracerProvider: %v", err)
+	}
+	t.Cleanup(func() { shutdown(context.Background()) })
+
+	fields := otel.GetTextMapPropagator().Fields()
+	for _, want := range []string{"traceparent", "baggage"} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("propagator fields = %v, missing %q", fields, want)
+		}
+	}
+}
+
+func TestParentBasedRatioZeroInheritsParentDecision(t *testing.T) {
+	sampler, err := newSampler("parentbased_traceidratio", "0")
+	if err != nil {
+		t.Fatalf("newSampler: %v", err)
+	}
+	propagator := propagation.TraceContext{}
+
+	tests := []struct {
+		name        string
+		upstream    sdktrace.Sampler
+		wantSampled bool
+		wantSpans   int
+	}{
+		{"sampled parent", sdktrace.AlwaysSample(), true, 1},
+		{"unsampled parent", sampler, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceA := sdktrace.NewTracerProvider(sdktrace.WithSampler(tt.upstream))
+			recorder := tracetest.NewSpanRecorder()
+			serviceB := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler), sdktrace.WithSpanProcessor(recorder))
+
+			ctx, root := serviceA.Tracer("service-a").Start(context.Background(), "service-a: handle-cep")
+			header := http.Header{}
+			propagator.Inject(ctx, propagation.HeaderCarrier(header))
+			root.End()
+
+			incoming := propagator.Extract(context.Background(), propagation.HeaderCarrier(header))
+			_, child := serviceB.Tracer("service-b").Start(incoming, "service-b: weather")
+			child.End()
+
+			if got := child.SpanContext().IsSampled(); got != tt.wantSampled {
+				t.Errorf("child sampled = %v, want %v", got, tt.wantSampled)
+			}
+			if child.SpanContext().TraceID() != root.SpanContext().TraceID() {
+				t.Error("child does not share the parent's trace id")
+			}
+			if got := len(recorder.Ended()); got != tt.wantSpans {
+				t.Errorf("recorded spans = %d, want %d", got, tt.wantSpans)
+			}
+		})
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler))
+	if _, root := provider.Tracer("service-b").Start(context.Background(), "root"); root.SpanContext().IsSampled() {
+		t.Error("root span sampled at ratio 0")
+	}
+}
+
+type retainingExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (retainingExporter) Shutdown(context.Context) error { return nil }
+
+func TestShutdownTracerFlushesPendingSpans(t *testing.T) {
+	exporter := retainingExporter{tracetest.NewInMemoryExporter()}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Hour)))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := tp.Tracer("service-a").Start(r.Context(), "service-a: handle-cep")
+		defer span.End()
+		w.WriteHeader(http.StatusOK)
+	}))
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	server.Close()
+
+	if got := len(exporter.GetSpans()); got != 0 {
+		t.Fatalf("spans exported before shutdown = %d, want 0", got)
+	}
+	if err := ShutdownTracer(tp.Shutdown, time.Second); err != nil {
+		t.Fatalf("ShutdownTracer: %v", err)
+	}
+	if got := len(exporter.GetSpans()); got != 1 {
+		t.Errorf("spans exported after shutdown = %d, want 1", got)
+	}
+}
+
+func TestNewSpanExporter(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{TracesExporterConsole, "*stdouttrace.Exporter", false},
+		{TracesExporterOTLP, "*otlptrace.Exporter", false},
+		{"", "*otlptrace.Exporter", false},
+		{TracesExporterNone, "<nil>", false},
+		{"zipkin", "<nil>", true},
+	}
+	for _, tt := range tests {
+		t.Run("OTEL_TRACES_EXPORTER="+tt.name, func(t *testing.T) {
+			exporter, err := newSpanExporter(context.Background(), tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newSpanExporter(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if exporter != nil {
+				t.Cleanup(func() { exporter.Shutdown(context.Background()) })
+			}
+			if got := fmt.Sprintf("%T", exporter); got != tt.want {
+				t.Errorf("newSpanExporter(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// blockingExporter hangs in ExportSpans until release is closed, ignoring the
+// context like a collector that never answers.
+type blockingExporter struct {
+	release chan struct{}
+}
+
+func (e blockingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	<-e.release
+	return nil
+}
+
+func (blockingExporter) Shutdown(context.Context) error { return nil }
+
+func TestShutdownTracerBoundsBlockingExporter(t *testing.T) {
+	exporter := blockingExporter{release: make(chan struct{})}
+	defer close(exporter.release)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Hour)))
+	_, span := tp.Tracer("service-a").Start(context.Background(), "service-a: handle-cep")
+	span.End()
+
+	const timeout = 100 * time.Millisecond
+	start := time.Now()
+	err := ShutdownTracer(tp.Shutdown, timeout)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ShutdownTracer() = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed > 5*timeout {
+		t.Errorf("ShutdownTracer returned after %s, want about %s", elapsed, timeout)
+	}
+}